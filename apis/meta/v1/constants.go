@@ -24,10 +24,18 @@ const (
 	AccessKeyIDKey = "access-key-id"
 	// SecretAccessKeyKey is the key in secrets where the secret access key is stored.
 	SecretAccessKeyKey = "secret-access-key"
+	// CustomerKeyKey is the key in secrets where the SSE-C customer key is stored.
+	CustomerKeyKey = "customer-key"
 	// DefaultGSTDebug is the default GST_DEBUG value set in the environment for pipelines.
 	DefaultGSTDebug = "4"
 	// DefaultDotInterval is the default interval to query a pipeline for graphs.
 	DefaultDotInterval = 3
+	// LiveConnectedFile is the file created by live pipelines once data from the stream is
+	// being recorded. It is used for the readiness of live pipelines.
+	LiveConnectedFile = "/tmp/.gst-live-connected"
+	// TerminationMessagePath is where the runner writes the result of a job, or its report when
+	// validating a pipeline. It is used as the termination message path of pipeline containers.
+	TerminationMessagePath = "/dev/termination-log"
 )
 
 // Annotations
@@ -47,6 +55,13 @@ const (
 	// JobBucketLabel is the label on a job to denote the bucket where the object is that
 	// is being processed.
 	JobBucketLabel = "pipelines.gst.io/bucket"
+	// JobChainLabel is the label on a job to denote the PipelineChain that created it.
+	JobChainLabel = "pipelines.gst.io/chain"
+	// JobChainStageLabel is the label on a job to denote the index of the PipelineChain stage
+	// it is running.
+	JobChainStageLabel = "pipelines.gst.io/chain-stage"
+	// JobValidationLabel is the label on a validation job to denote the pipeline it is validating.
+	JobValidationLabel = "pipelines.gst.io/validation"
 )
 
 // Environment Variables
@@ -59,12 +74,33 @@ const (
 	MinIOSinkAccessKeyIDEnvVar = "MINIO_SINK_ACCESS_KEY_ID"
 	// The environment variable where the secret access key for the sink bucket is stored.
 	MinIOSinkSecretAccessKeyEnvVar = "MINIO_SINK_SECRET_ACCESS_KEY"
+	// The environment variable where the access key id for the debug destination is stored.
+	MinIODebugAccessKeyIDEnvVar = "MINIO_DEBUG_ACCESS_KEY_ID"
+	// The environment variable where the secret access key for the debug destination is stored.
+	MinIODebugSecretAccessKeyEnvVar = "MINIO_DEBUG_SECRET_ACCESS_KEY"
+	// The environment variable where the SSE-C customer key for the src bucket is stored.
+	MinIOSrcCustomerKeyEnvVar = "MINIO_SRC_SSE_CUSTOMER_KEY"
+	// The environment variable where the SSE-C customer key for the sink bucket is stored.
+	MinIOSinkCustomerKeyEnvVar = "MINIO_SINK_SSE_CUSTOMER_KEY"
+	// The environment variable where the SSE-C customer key for the debug destination is stored.
+	MinIODebugCustomerKeyEnvVar = "MINIO_DEBUG_SSE_CUSTOMER_KEY"
 	// The environment variable where the pipeline config is serialized and set.
 	JobPipelineConfigEnvVar = "GST_PIPELINE_CONFIG"
 	// The environment variable where the source object is serialized and set.
 	JobSrcObjectsEnvVar = "GST_PIPELINE_SRC_OBJECT"
 	// The environment variable where the sink objects are serialized and set.
 	JobSinkObjectsEnvVar = "GST_PIPELINE_SINK_OBJECTS"
+	// The environment variable where the input objects are serialized and set for pipelines
+	// that read more than one object.
+	JobInputObjectsEnvVar = "GST_PIPELINE_INPUT_OBJECTS"
+	// The environment variable where the stream source is serialized and set for live pipelines.
+	JobLiveSourceEnvVar = "GST_PIPELINE_LIVE_SOURCE"
+	// The environment variable where the segment configuration is serialized and set for live
+	// pipelines.
+	JobSegmentsEnvVar = "GST_PIPELINE_SEGMENTS"
+	// The environment variable set to "true" to have the runner validate the pipeline instead
+	// of running it.
+	JobValidateEnvVar = "GST_PIPELINE_VALIDATE"
 	// The environment variable where the name of the pipeline being watched is set for watcher
 	// processes.
 	WatcherPipelineNameEnvVar = "GST_WATCH_PIPELINE_NAME"
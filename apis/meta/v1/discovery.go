@@ -0,0 +1,90 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultDiscoveryTimeout is the default time given to the discovery of a source object.
+const DefaultDiscoveryTimeout = 30 * time.Second
+
+// metadataObjectSuffix is appended to the key of an output to name its discovered metadata.
+const metadataObjectSuffix = ".metadata.json"
+
+// DiscoveryConfig represents a configuration for probing the contents of source objects before
+// they are processed.
+type DiscoveryConfig struct {
+	// The time given to the discovery of a source object. Defaults to 30s.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Whether to also write the discovered metadata as a JSON object next to the first output,
+	// named after the output with a `.metadata.json` suffix. It is only written once the pipeline
+	// has finished.
+	WriteMetadata bool `json:"writeMetadata,omitempty"`
+}
+
+// MediaInfo describes the contents of a media object.
+type MediaInfo struct {
+	// The media type of the container, for example video/quicktime. Empty for elementary streams.
+	Container string `json:"container,omitempty"`
+	// The duration of the media.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// The overall bitrate of the media in bits per second.
+	Bitrate uint `json:"bitrate,omitempty"`
+	// Whether the media can be seeked.
+	Seekable bool `json:"seekable,omitempty"`
+	// The streams found in the media.
+	Streams []*MediaStream `json:"streams,omitempty"`
+}
+
+// MediaStream describes a single stream in a media object.
+type MediaStream struct {
+	// The type of the stream. One of video, image, audio or subtitle.
+	Type string `json:"type"`
+	// The media type of the stream's codec, for example video/x-h264.
+	Codec string `json:"codec,omitempty"`
+	// The bitrate of the stream in bits per second.
+	Bitrate uint `json:"bitrate,omitempty"`
+	// The width of video streams.
+	Width uint `json:"width,omitempty"`
+	// The height of video streams.
+	Height uint `json:"height,omitempty"`
+	// The framerate of video streams as a fraction.
+	Framerate string `json:"framerate,omitempty"`
+	// The sample rate of audio streams.
+	SampleRate uint `json:"sampleRate,omitempty"`
+	// The number of channels in audio streams.
+	Channels uint `json:"channels,omitempty"`
+	// The language of audio and subtitle streams.
+	Language string `json:"language,omitempty"`
+}
+
+// GetTimeout returns the time given to the discovery of a source object.
+func (d *DiscoveryConfig) GetTimeout() time.Duration {
+	if d.Timeout == nil || d.Timeout.Duration <= 0 {
+		return DefaultDiscoveryTimeout
+	}
+	return d.Timeout.Duration
+}
+
+// MetadataObjectName returns the name of the object discovered metadata is written to next to the
+// given output object.
+func MetadataObjectName(outputKey string) string {
+	return outputKey + metadataObjectSuffix
+}
@@ -24,19 +24,24 @@ type ElementConfig struct {
 	Name string `json:"name,omitempty"`
 	// Applies an alias to this element in the pipeline configuration. This allows you to specify an
 	// element block with this value as the name and have it act as a "goto" or "linkto" while building
-	// the pipeline. Note that the aliases "video-out" and "audio-out" are reserved for internal use.
+	// the pipeline. Note that the aliases "video-out", "audio-out", "concat-video" and "concat-audio" are reserved
+	// for internal use.
 	Alias string `json:"alias,omitempty"`
 	// The alias to an element to treat as this configuration. Useful for directing the output of elements
-	// with multiple src pads, such as decodebin.
+	// with multiple src pads, such as decodebin. A specific src pad or pad template can be targeted by
+	// appending its name to the alias, e.g. "demux.audio_0" or "tee.src_%u".
 	GoTo string `json:"goto,omitempty"`
 	// The alias to an element to link the previous element's sink pad to. Useful for directing the branches of
 	// a multi-stream pipeline to a muxer. A linkto almost always needs to be followed by a goto, except when
 	// the element being linked to is next in the pipeline, in which case you can omit the linkto entirely.
+	// A specific sink pad or pad template can be targeted by appending its name to the alias, e.g. "mux.video_%u".
 	LinkTo string `json:"linkto,omitempty"`
-	// Optional properties to apply to this element. To not piss off the CRD generator values are
-	// declared as a string, but almost anything that can be passed to gst-launch-1.0 will work.
-	// Caps will be parsed from their string representation.
-	Properties map[string]string `json:"properties,omitempty"`
+	// Optional properties to apply to this element. Values may be strings in any format that can be
+	// passed to gst-launch-1.0, such as caps, fractions and structures, or JSON numbers, booleans and
+	// lists. Enums may be given by their nick, name or number, and flags as a list of those separated
+	// by "+" or "|" (e.g. "tune: zerolatency+fastdecode"). Properties holding an element take the name
+	// of the factory to create it from.
+	Properties map[string]PropertyValue `json:"properties,omitempty"`
 }
 
 // LinkToVideoOut is used during split pipelines to designate the src of a video sink
@@ -44,3 +49,15 @@ const LinkToVideoOut = "video-out"
 
 // LinkToAudioOut is used during split pipelines to designate the src of an audio sink
 const LinkToAudioOut = "audio-out"
+
+// GoToConcatVideo is used during concat pipelines to designate the concatenated video streams
+const GoToConcatVideo = "concat-video"
+
+// GoToConcatAudio is used during concat pipelines to designate the concatenated audio streams
+const GoToConcatAudio = "concat-audio"
+
+// LaunchPlaceholderSrc is used in launch descriptions to designate the source object
+const LaunchPlaceholderSrc = "src"
+
+// LaunchPlaceholderSink is used in launch descriptions to designate the sink for all streams
+const LaunchPlaceholderSink = "sink"
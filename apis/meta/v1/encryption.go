@@ -0,0 +1,110 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EncryptionType represents a type of server-side encryption.
+// +kubebuilder:validation:Enum=SSE-S3;SSE-KMS;SSE-C
+type EncryptionType string
+
+const (
+	// EncryptionSSES3 encrypts objects with keys managed by the server.
+	EncryptionSSES3 EncryptionType = "SSE-S3"
+	// EncryptionSSEKMS encrypts objects with a key held in the KMS of the server.
+	EncryptionSSEKMS EncryptionType = "SSE-KMS"
+	// EncryptionSSEC encrypts objects with a key supplied by the client.
+	EncryptionSSEC EncryptionType = "SSE-C"
+)
+
+// EncryptionConfig represents the server-side encryption of objects.
+type EncryptionConfig struct {
+	// The type of server-side encryption. SSE-S3 encrypts objects with keys managed by the server,
+	// SSE-KMS with the key given by `kmsKeyID`, and SSE-C with the key in `customerKeySecret`.
+	Type EncryptionType `json:"type"`
+	// The ID of the KMS key to encrypt objects with when using SSE-KMS.
+	KMSKeyID string `json:"kmsKeyID,omitempty"`
+	// The secret holding the key to encrypt objects with when using SSE-C. The `customer-key` key
+	// must contain the base64-encoded 256-bit key. The key is passed to pipeline pods by reference
+	// to the secret.
+	CustomerKeySecret *corev1.LocalObjectReference `json:"customerKeySecret,omitempty"`
+}
+
+// IsCustomerKey returns true if objects are encrypted with a key supplied by the client.
+func (e *EncryptionConfig) IsCustomerKey() bool {
+	return e != nil && e.Type == EncryptionSSEC
+}
+
+// GetCustomerKeySecret returns the name of the secret holding the SSE-C customer key.
+func (e *EncryptionConfig) GetCustomerKeySecret() (string, error) {
+	if e.CustomerKeySecret == nil {
+		return "", errors.New("No secret reference included in the CR for the SSE-C customer key")
+	}
+	return e.CustomerKeySecret.Name, nil
+}
+
+// GetServerSide returns the encryption to apply to objects, with the given base64-encoded customer
+// key used for SSE-C. It returns nil if objects are not encrypted.
+func (e *EncryptionConfig) GetServerSide(customerKey string) (encrypt.ServerSide, error) {
+	if e == nil {
+		return nil, nil
+	}
+	switch e.Type {
+	case EncryptionSSES3:
+		return encrypt.NewSSE(), nil
+	case EncryptionSSEKMS:
+		return encrypt.NewSSEKMS(e.KMSKeyID, nil)
+	case EncryptionSSEC:
+		key, err := base64.StdEncoding.DecodeString(customerKey)
+		if err != nil {
+			return nil, fmt.Errorf("The SSE-C customer key is not valid base64: %s", err.Error())
+		}
+		return encrypt.NewSSEC(key)
+	}
+	return nil, fmt.Errorf("Unknown encryption type %q", e.Type)
+}
+
+// GetServerSideEncryption attempts to return the encryption for objects in this config, retrieving
+// the SSE-C customer key from its secret with the given client in the given namespace.
+func (m *MinIOConfig) GetServerSideEncryption(client client.Client, namespace string) (encrypt.ServerSide, error) {
+	if !m.Encryption.IsCustomerKey() {
+		return m.Encryption.GetServerSide("")
+	}
+	secretName, err := m.Encryption.GetCustomerKeySecret()
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+		return nil, err
+	}
+	customerKey, ok := secret.Data[CustomerKeyKey]
+	if !ok {
+		return nil, fmt.Errorf("No %s in secret %s/%s", CustomerKeyKey, namespace, secretName)
+	}
+	return m.Encryption.GetServerSide(string(customerKey))
+}
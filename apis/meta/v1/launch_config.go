@@ -16,6 +16,8 @@ limitations under the License.
 
 package v1
 
+import "strings"
+
 // GstLaunchConfig is a slice of ElementConfigs that contain internal fields used for
 // dynamic linking.
 type GstLaunchConfig []*GstElementConfig
@@ -39,12 +41,47 @@ func (g GstLaunchConfig) GetByAlias(alias string) *GstElementConfig {
 	return nil
 }
 
+// HasGoTo returns true if any element in the configuration jumps to the given alias.
+func (g GstLaunchConfig) HasGoTo(alias string) bool {
+	for _, elem := range g {
+		if goTo, _ := elem.GetGoTo(); goTo == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGoTo returns the alias of the element this configuration jumps to, and the name of the src
+// pad or pad template on it to link from, if one was given.
+func (e *ElementConfig) GetGoTo() (alias, pad string) { return splitPadRef(e.GoTo) }
+
+// GetLinkTo returns the alias of the element this configuration links to, and the name of the sink
+// pad or pad template on it to link to, if one was given.
+func (e *ElementConfig) GetLinkTo() (alias, pad string) { return splitPadRef(e.LinkTo) }
+
+// splitPadRef splits a reference in the form of <alias>.<pad> into its parts. The pad is empty
+// when the reference only names an alias.
+func splitPadRef(ref string) (alias, pad string) {
+	if idx := strings.Index(ref, "."); idx != -1 {
+		return ref[:idx], ref[idx+1:]
+	}
+	return ref, ""
+}
+
 // GstElementConfig is an extension of the ElementConfig struct providing
 // private fields for internal tracking while building a dynamic pipeline.
 type GstElementConfig struct {
 	*ElementConfig
 	pipelineName string
-	peers        []*GstElementConfig
+	peers        []*GstElementPeer
+	padTargets   []string
+}
+
+// GstElementPeer is an element to link dynamically added src pads to. When Pad is set, the
+// link is made to the sink pad or pad template with that name.
+type GstElementPeer struct {
+	*GstElementConfig
+	Pad string
 }
 
 // SetPipelineName sets the name that was assigned to this element by the pipeline for
@@ -55,13 +92,23 @@ func (e *GstElementConfig) SetPipelineName(name string) { e.pipelineName = name
 func (e *GstElementConfig) GetPipelineName() string { return e.pipelineName }
 
 // AddPeer will add a peer to this configuration. It is used for determining which
-// sink pads to pair with dynamically added src pads.
-func (e *GstElementConfig) AddPeer(peer *GstElementConfig) {
+// sink pads to pair with dynamically added src pads. The pad may be empty to use the
+// peer's static sink pad.
+func (e *GstElementConfig) AddPeer(peer *GstElementConfig, pad string) {
 	if e.peers == nil {
-		e.peers = make([]*GstElementConfig, 0)
+		e.peers = make([]*GstElementPeer, 0)
 	}
-	e.peers = append(e.peers, peer)
+	e.peers = append(e.peers, &GstElementPeer{GstElementConfig: peer, Pad: pad})
 }
 
 // GetPeers returns the peers registered for this element.
-func (e *GstElementConfig) GetPeers() []*GstElementConfig { return e.peers }
+func (e *GstElementConfig) GetPeers() []*GstElementPeer { return e.peers }
+
+// AddPadTarget records that the src pad or pad template with the given name is linked
+// explicitly, so it is left alone when routing dynamically added pads to peers.
+func (e *GstElementConfig) AddPadTarget(name string) {
+	e.padTargets = append(e.padTargets, name)
+}
+
+// GetPadTargets returns the src pads and pad templates that are linked explicitly.
+func (e *GstElementConfig) GetPadTargets() []string { return e.padTargets }
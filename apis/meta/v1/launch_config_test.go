@@ -0,0 +1,51 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import "testing"
+
+func TestSplitPadRef(t *testing.T) {
+	tests := []struct {
+		ref           string
+		expectedAlias string
+		expectedPad   string
+	}{
+		{ref: "demux", expectedAlias: "demux"},
+		{ref: "demux.audio_%u", expectedAlias: "demux", expectedPad: "audio_%u"},
+		{ref: "demux.video_0", expectedAlias: "demux", expectedPad: "video_0"},
+		{ref: "mux.sink.1", expectedAlias: "mux", expectedPad: "sink.1"},
+		{ref: "demux.", expectedAlias: "demux"},
+		{ref: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			alias, pad := splitPadRef(tt.ref)
+			if alias != tt.expectedAlias || pad != tt.expectedPad {
+				t.Errorf("Expected alias %q and pad %q, got %q and %q", tt.expectedAlias, tt.expectedPad, alias, pad)
+			}
+		})
+	}
+
+	cfg := &ElementConfig{GoTo: "demux.audio_%u", LinkTo: "mux.sink_%u"}
+	if alias, pad := cfg.GetGoTo(); alias != "demux" || pad != "audio_%u" {
+		t.Errorf("Expected goto demux.audio_%%u, got %s.%s", alias, pad)
+	}
+	if alias, pad := cfg.GetLinkTo(); alias != "mux" || pad != "sink_%u" {
+		t.Errorf("Expected linkto mux.sink_%%u, got %s.%s", alias, pad)
+	}
+}
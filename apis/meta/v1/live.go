@@ -0,0 +1,66 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Default values for live stream configurations
+const (
+	// DefaultSegmentDuration is the default duration of recorded segments.
+	DefaultSegmentDuration = time.Minute
+	// DefaultSegmentMuxer is the default muxer used to write recorded segments.
+	DefaultSegmentMuxer = "mpegtsmux"
+)
+
+// LiveSourceConfig is the configuration for a network stream source.
+type LiveSourceConfig struct {
+	// The URI of the stream. Supported schemes are rtsp, rtmp, srt and udp, e.g.
+	// "rtsp://camera.local:554/stream" or "srt://encoder.local:8888".
+	// +kubebuilder:validation:Pattern=`^(rtsps?|rtmps?|srt|udp)://`
+	URI string `json:"uri"`
+}
+
+// GetURI returns the URI of the stream.
+func (l *LiveSourceConfig) GetURI() string { return l.URI }
+
+// SegmentConfig configures how a live stream is recorded into rolling segments.
+type SegmentConfig struct {
+	// The duration of each segment. Defaults to 1 minute.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// The name of the muxer element used to write each segment. Defaults to mpegtsmux.
+	Muxer string `json:"muxer,omitempty"`
+}
+
+// GetDuration returns the duration of each segment.
+func (s *SegmentConfig) GetDuration() time.Duration {
+	if s == nil || s.Duration == nil || s.Duration.Duration <= 0 {
+		return DefaultSegmentDuration
+	}
+	return s.Duration.Duration
+}
+
+// GetMuxer returns the muxer for writing segments.
+func (s *SegmentConfig) GetMuxer() string {
+	if s == nil || s.Muxer == "" {
+		return DefaultSegmentMuxer
+	}
+	return s.Muxer
+}
@@ -34,4 +34,8 @@ type PipelineState string
 const (
 	// PipelineInSync represents that the pipeline configuration is in sync with the watchers.
 	PipelineInSync PipelineState = "InSync"
+	// PipelineValid represents the result of validating the pipeline configuration with the runner.
+	PipelineValid PipelineState = "Valid"
+	// PipelineScheduled represents whether the schedule of the pipeline could be parsed.
+	PipelineScheduled PipelineState = "Scheduled"
 )
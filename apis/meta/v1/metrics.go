@@ -0,0 +1,74 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultMetricsPort is the default port the runner serves metrics on.
+const DefaultMetricsPort int32 = 8080
+
+// MetricsConfig represents a configuration for the HTTP server the runner exposes while a
+// pipeline is running. The server provides Prometheus metrics at /metrics, and the current
+// pipeline graph at /pipeline as JSON and at /pipeline.dot in the dot format.
+type MetricsConfig struct {
+	// The port to serve on. Defaults to 8080.
+	Port int32 `json:"port,omitempty"`
+}
+
+// GetMetricsPort returns the port the runner serves metrics on, or 0 if metrics are disabled.
+func (p *PipelineConfig) GetMetricsPort() int32 {
+	if p.Metrics == nil {
+		return 0
+	}
+	if p.Metrics.Port == 0 {
+		return DefaultMetricsPort
+	}
+	return p.Metrics.Port
+}
+
+// GetPodAnnotations returns the annotations to place on pods running the pipeline so their
+// metrics are scraped.
+func (p *PipelineConfig) GetPodAnnotations() map[string]string {
+	port := p.GetMetricsPort()
+	if port == 0 {
+		return nil
+	}
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   strconv.Itoa(int(port)),
+		"prometheus.io/path":   "/metrics",
+	}
+}
+
+// GetContainerPorts returns the ports to declare on the container running the pipeline.
+func (p *PipelineConfig) GetContainerPorts() []corev1.ContainerPort {
+	port := p.GetMetricsPort()
+	if port == 0 {
+		return nil
+	}
+	return []corev1.ContainerPort{
+		{
+			Name:          "metrics",
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+}
@@ -23,10 +23,12 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"path"
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -60,10 +62,24 @@ type MinIOConfig struct {
 	// buckets and prefixes.
 	Prefix string `json:"key,omitempty"`
 	// A regular expression to filter out items placed in the `key`. Only makes sense in the context of a src
-	// config. This can be useful when chaining pipelines. You may want to exclude the "*_tmp" expression to
-	// filter out the temporary objects created while the miniosink is rendering the output of a pipeline, since
-	// it first creates chunked objects, and then pieces them together with the ComposeObject API.
+	// config. This can be useful when chaining pipelines.
 	Exclude string `json:"exclude,omitempty"`
+	// The content type to store objects with. Only makes sense in the context of a sink config. When empty,
+	// it is derived from the caps negotiated for the output, for example `video/mp4` for the output of mp4mux.
+	ContentType string `json:"contentType,omitempty"`
+	// The Content-Disposition header to store objects with. Only makes sense in the context of a sink config.
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+	// The Cache-Control header to store objects with. Only makes sense in the context of a sink config.
+	CacheControl string `json:"cacheControl,omitempty"`
+	// User metadata to store objects with. Only makes sense in the context of a sink config.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Tags to apply to objects. Only makes sense in the context of a sink config.
+	Tags map[string]string `json:"tags,omitempty"`
+	// The storage class to store objects in. Only makes sense in the context of a sink config.
+	StorageClass string `json:"storageClass,omitempty"`
+	// The server-side encryption of objects. In the context of a src config, this is required to read
+	// objects encrypted with SSE-C. In the context of a sink config, outputs are stored encrypted.
+	Encryption *EncryptionConfig `json:"encryption,omitempty"`
 	// The secret that contains the credentials for connecting to MinIO. The secret must contain
 	// two keys. The `access-key-id` key must contain the contents of the Access Key ID. The
 	// `secret-access-key` key must contain the contents of the Secret Access Key.
@@ -180,6 +196,48 @@ func (m *MinIOConfig) GetDestinationKey(objectKey string) string {
 	return path.Join(strings.TrimSuffix(m.GetPrefix(), "/"), path.Base(objectKey))
 }
 
+// GetOutputKey computes the name of an output object that is not named after a single source
+// object, from the given base name and extension. The key is executed as a template with "SrcName"
+// set to the name and "SrcExt" to the extension. If the key is not a template, or it fails to
+// execute, the output is named by the name and extension inside the key prefix.
+func (m *MinIOConfig) GetOutputKey(name, ext string) string {
+	tmpl := m.GetPrefix()
+	for strings.Contains(tmpl, "{{") {
+		var buf bytes.Buffer
+		t, err := template.New("").Funcs(sprig.TxtFuncMap()).Parse(tmpl)
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		if err := t.Execute(&buf, map[string]string{
+			"SrcName": name,
+			"SrcExt":  ext,
+		}); err != nil {
+			fmt.Println(err)
+			break
+		}
+		return buf.String()
+	}
+	return path.Join(strings.TrimSuffix(tmpl, "/"), name+ext)
+}
+
+// GetEncodedMetadata returns the user metadata in the form taken by the miniosink, or an empty string
+// if there is none.
+func (m *MinIOConfig) GetEncodedMetadata() string { return encodeValues(m.Metadata) }
+
+// GetEncodedTags returns the tags in the form taken by the miniosink, or an empty string if there are
+// none.
+func (m *MinIOConfig) GetEncodedTags() string { return encodeValues(m.Tags) }
+
+// encodeValues encodes the given map as a URL query string.
+func encodeValues(values map[string]string) string {
+	query := url.Values{}
+	for k, v := range values {
+		query.Set(k, v)
+	}
+	return query.Encode()
+}
+
 // GetExcludeRegex returns the regex to use for excluding objects, or nil if not present
 // or any error.
 func (m *MinIOConfig) GetExcludeRegex() *regexp.Regexp {
@@ -193,3 +251,29 @@ func (m *MinIOConfig) GetExcludeRegex() *regexp.Regexp {
 	}
 	return re
 }
+
+// GetSegmentKey computes the key for a segment recorded from a live stream. The `key` is
+// executed as a template with "Time" set to the time the segment started and "Index" set to
+// the number of the segment since the recording started. If the key is not a template, or it
+// fails to execute, the segments are named by their start time inside the key prefix.
+func (m *MinIOConfig) GetSegmentKey(index uint, start time.Time) string {
+	tmpl := m.GetPrefix()
+	start = start.UTC()
+	for strings.Contains(tmpl, "{{") {
+		var buf bytes.Buffer
+		t, err := template.New("").Funcs(sprig.TxtFuncMap()).Parse(tmpl)
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		if err := t.Execute(&buf, map[string]interface{}{
+			"Time":  start,
+			"Index": index,
+		}); err != nil {
+			fmt.Println(err)
+			break
+		}
+		return buf.String()
+	}
+	return path.Join(strings.TrimSuffix(tmpl, "/"), fmt.Sprintf("%s_%05d", start.Format("20060102T150405Z"), index))
+}
@@ -22,6 +22,13 @@ type Object struct {
 	// a source object this is pulled from a watch event. In the context of a destination
 	// this is computed by the controller from the user supplied configuration.
 	Name string `json:"name"`
+	// The version of the object to read. In the context of a source object this is pulled
+	// from the watch event on versioned buckets, so the job reads the object as it was when
+	// the event fired even if the key is overwritten while it is processed.
+	VersionID string `json:"versionID,omitempty"`
+	// The ETag of the object when the job was created. In the context of a source object
+	// this is used to detect overwrites on buckets without versioning.
+	ETag string `json:"etag,omitempty"`
 	// The endpoint and bucket configurations for the object.
 	Config *SourceSinkConfig `json:"config"`
 	// The type of the stream for this object. Only applies to sinks. For a split transform
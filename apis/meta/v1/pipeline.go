@@ -24,6 +24,7 @@ import (
 
 	"github.com/tinyzimmer/gst-pipeline-operator/pkg/version"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // PipelineConfig represents a series of elements through which to pass the contents of
@@ -38,12 +39,55 @@ type PipelineConfig struct {
 	// works for linear pipelines. That is to say, not the syntax used by `gst-launch-1.0` that
 	// allows naming elements and referencing them later in the pipeline. For complex handling
 	// of multiple streams decodebin will still be better to work with for now, despite its
-	// shortcomings.
+	// shortcomings, or the pipeline can be given as a `launch` description instead.
 	Elements []*ElementConfig `json:"elements,omitempty"`
+	// A pipeline description in the syntax used by `gst-launch-1.0`. Using this is mutually exclusive
+	// with `elements`. The description references the source and sink objects by the placeholder names
+	// `src`, `sink`, `video-out` and `audio-out`, for example `src. ! decodebin ! videoconvert ! x264enc ! mp4mux ! sink.`.
+	// This is currently supported for pipelines reading a single source object.
+	Launch string `json:"launch,omitempty"`
+	// A reference to a PipelineTemplate in the same namespace to use instead of `elements`. The elements
+	// of the template are rendered with the given parameters. This is currently supported by Transform and
+	// SplitTransform pipelines.
+	Template *TemplateReference `json:"template,omitempty"`
 	// Resource restraints to place on jobs created for this pipeline.
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// What to do when the pod running the pipeline is terminated before it finishes. Finalize, the
+	// default, sends an end-of-stream through the pipeline so outputs are completed with what was
+	// processed so far, and aborts if that does not complete within the grace period. Abort stops the
+	// pipeline immediately and removes any partially uploaded outputs.
+	OnTerminate TerminationPolicy `json:"onTerminate,omitempty"`
+	// The time given to the pipeline to terminate once its pod is deleted. Defaults to 30s.
+	TerminationGracePeriod *metav1.Duration `json:"terminationGracePeriod,omitempty"`
+	// Whether to write the result of each job as a JSON object next to its first output, named
+	// after the output with a `.result.json` suffix. The result is always recorded in the status of
+	// the Job.
+	WriteResult bool `json:"writeResult,omitempty"`
+	// Probe the source object with a discoverer before processing it. The container, duration and
+	// streams found are recorded in the result of the job.
+	Discover *DiscoveryConfig `json:"discover,omitempty"`
+	// Serve Prometheus metrics and a view of the pipeline graph over HTTP while the pipeline runs.
+	// Pods running the pipeline are annotated for scraping.
+	Metrics *MetricsConfig `json:"metrics,omitempty"`
+	// Fail the run when the pipeline stops making progress, or does not shut down in time once it
+	// has finished. The elements that last passed data are reported with the error.
+	Watchdog *WatchdogConfig `json:"watchdog,omitempty"`
 }
 
+// TerminationPolicy represents the action taken when a running pipeline is terminated.
+// +kubebuilder:validation:Enum=Finalize;Abort
+type TerminationPolicy string
+
+const (
+	// TerminationPolicyFinalize sends an end-of-stream through the pipeline to complete its outputs.
+	TerminationPolicyFinalize TerminationPolicy = "Finalize"
+	// TerminationPolicyAbort stops the pipeline and removes partially uploaded outputs.
+	TerminationPolicyAbort TerminationPolicy = "Abort"
+)
+
+// DefaultTerminationGracePeriod is the default time given to a pipeline to terminate.
+const DefaultTerminationGracePeriod = 30 * time.Second
+
 // DebugConfig represents debug configurations for a GStreamer pipeline.
 type DebugConfig struct {
 	// The level of log output to produce from the gstreamer process. This value gets set to
@@ -52,21 +96,28 @@ type DebugConfig struct {
 	// Dot specifies to dump a dot file of the pipeline layout for debugging. The extending
 	// object allows for additional configurations to the output.
 	Dot *DotConfig `json:"dot,omitempty"`
+	// Where to write dot graphs, rendered images and other debug output. The `key` of the MinIO
+	// configuration is used as a prefix for everything written, and its credentials secret is required.
+	// When omitted, debug output is written to the bucket of the source object with its credentials.
+	// A dedicated destination also allows debugging live pipelines, which have no source object.
+	Destination *SourceSinkConfig `json:"destination,omitempty"`
 }
 
 // DotConfig represents a configuration for the dot output of a pipeline.
 type DotConfig struct {
-	// The path to save files. The configuration other than the path is assumed to be that of
-	// the source of the pipeline. For example, for a MinIO source, this should be a prefix in
-	// the same bucket as the source (but not overlapping with the watch prefix otherwise an infinite
-	// loop will happen). The files will be saved in directories matching the source object's name with
-	// the _debug suffix.
+	// The path to save files. Unless the debug configuration has its own destination, the configuration
+	// other than the path is assumed to be that of the source of the pipeline. For example, for a MinIO
+	// source, this should be a prefix in the same bucket as the source (but not overlapping with the watch
+	// prefix otherwise an infinite loop will happen). The files will be saved in directories matching the
+	// source object's name with the _debug suffix.
 	Path string `json:"path,omitempty"`
 	// Specify to also render the pipeline graph to images in the given format. Accepted formats are
 	// png, svg, or jpg.
 	Render string `json:"render,omitempty"`
 	// Whether to save timestamped versions of the pipeline layout. This will produce a new graph for every
-	// interval specified by Interval. The default is to only keep the latest graph.
+	// interval specified by Interval. The default is to only keep the latest graph. Graphs are also captured
+	// when the pipeline changes state, on an error and at the end of the stream, with the reason appended
+	// to their names, for example pipeline_paused-to-playing.dot or pipeline_error.dot.
 	Timestamped bool `json:"timestamped,omitempty"`
 	// The interval in seconds to save pipeline graphs. Defaults to every 3 seconds.
 	Interval int `json:"interval,omitempty"`
@@ -101,12 +152,25 @@ func (p *PipelineConfig) GetDotInterval() time.Duration {
 	return time.Duration(p.Debug.Dot.Interval) * time.Second
 }
 
+// GetDebugDestination returns the dedicated destination for debug output, or nil if it should be
+// written next to the source object.
+func (p *PipelineConfig) GetDebugDestination() *SourceSinkConfig {
+	if p.Debug == nil || p.Debug.Destination == nil || p.Debug.Destination.MinIO == nil {
+		return nil
+	}
+	return p.Debug.Destination
+}
+
 // GetDotPath returns the path to save dot graphs based on the given source key.
 func (p *PipelineConfig) GetDotPath(srcKey string) string {
 	if p.Debug == nil || p.Debug.Dot == nil {
 		return ""
 	}
-	return path.Join(p.Debug.Dot.Path, fmt.Sprintf("%s_debug", path.Base(srcKey)))
+	dotPath := path.Join(p.Debug.Dot.Path, fmt.Sprintf("%s_debug", path.Base(srcKey)))
+	if dest := p.GetDebugDestination(); dest != nil {
+		return path.Join(dest.MinIO.GetPrefix(), dotPath)
+	}
+	return dotPath
 }
 
 // GetDotRenderFormat returns the image format that the dot graphs should be encoded to
@@ -118,6 +182,28 @@ func (p *PipelineConfig) GetDotRenderFormat() string {
 	return p.Debug.Dot.Render
 }
 
+// GetTerminationPolicy returns the action to take when the pipeline is terminated.
+func (p *PipelineConfig) GetTerminationPolicy() TerminationPolicy {
+	if p.OnTerminate == "" {
+		return TerminationPolicyFinalize
+	}
+	return p.OnTerminate
+}
+
+// GetTerminationGracePeriod returns the time given to the pipeline to terminate.
+func (p *PipelineConfig) GetTerminationGracePeriod() time.Duration {
+	if p.TerminationGracePeriod == nil {
+		return DefaultTerminationGracePeriod
+	}
+	return p.TerminationGracePeriod.Duration
+}
+
+// GetTerminationGracePeriodSeconds returns the grace period to set on pods running the pipeline.
+func (p *PipelineConfig) GetTerminationGracePeriodSeconds() *int64 {
+	secs := int64(p.GetTerminationGracePeriod().Seconds())
+	return &secs
+}
+
 // GetImage returns the container image to use for the gstreamer pipelines.
 func (p *PipelineConfig) GetImage() string {
 	if p.Image != "" {
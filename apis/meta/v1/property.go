@@ -0,0 +1,76 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PropertyValue is the value of an element property. It can be given as a string in the format
+// accepted by gst-launch-1.0, or as a JSON number, boolean or list.
+// +kubebuilder:validation:XPreserveUnknownFields
+// +kubebuilder:validation:Type=""
+type PropertyValue struct {
+	// Raw is the JSON encoding of the value.
+	Raw []byte `json:"-"`
+}
+
+// NewStringPropertyValue returns a PropertyValue holding the given string.
+func NewStringPropertyValue(s string) PropertyValue {
+	raw, _ := json.Marshal(s)
+	return PropertyValue{Raw: raw}
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p PropertyValue) MarshalJSON() ([]byte, error) {
+	if len(p.Raw) == 0 {
+		return []byte("null"), nil
+	}
+	return p.Raw, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *PropertyValue) UnmarshalJSON(data []byte) error {
+	p.Raw = append(p.Raw[:0], data...)
+	return nil
+}
+
+// GetValue returns the decoded value. Strings, booleans and lists are returned as their Go types,
+// while numbers are returned as a json.Number to preserve their representation.
+func (p PropertyValue) GetValue() (interface{}, error) {
+	if len(p.Raw) == 0 {
+		return nil, nil
+	}
+	var out interface{}
+	dec := json.NewDecoder(bytes.NewReader(p.Raw))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetString returns the value if it is a string. False is returned for any other type.
+func (p PropertyValue) GetString() (string, bool) {
+	val, err := p.GetValue()
+	if err != nil {
+		return "", false
+	}
+	s, ok := val.(string)
+	return s, ok
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPropertyValueJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		expectedValue  interface{}
+		expectedString string
+		isString       bool
+	}{
+		{name: "string", json: `"video/x-raw,width=1280"`, expectedValue: "video/x-raw,width=1280", expectedString: "video/x-raw,width=1280", isString: true},
+		{name: "integer", json: `4000`, expectedValue: json.Number("4000")},
+		{name: "large integer", json: `18446744073709551615`, expectedValue: json.Number("18446744073709551615")},
+		{name: "float", json: `0.5`, expectedValue: json.Number("0.5")},
+		{name: "boolean", json: `true`, expectedValue: true},
+		{name: "list", json: `[1,"two"]`, expectedValue: []interface{}{json.Number("1"), "two"}},
+		{name: "null", json: `null`, expectedValue: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := `{"prop":` + tt.json + `}`
+			props := map[string]PropertyValue{}
+			if err := json.Unmarshal([]byte(in), &props); err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := props["prop"].GetValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, tt.expectedValue) {
+				t.Errorf("Expected value %#v, got %#v", tt.expectedValue, value)
+			}
+			s, ok := props["prop"].GetString()
+			if ok != tt.isString || s != tt.expectedString {
+				t.Errorf("Expected string %q (%v), got %q (%v)", tt.expectedString, tt.isString, s, ok)
+			}
+
+			out, err := json.Marshal(props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != in {
+				t.Errorf("Expected %s to be marshaled unchanged, got %s", in, out)
+			}
+		})
+	}
+}
+
+func TestPropertyValueEmpty(t *testing.T) {
+	var p PropertyValue
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "null" {
+		t.Errorf("Expected an empty value to marshal to null, got %s", out)
+	}
+	if value, err := p.GetValue(); err != nil || value != nil {
+		t.Errorf("Expected no value, got %v, %v", value, err)
+	}
+	if _, ok := p.GetString(); ok {
+		t.Error("Expected an empty value not to be a string")
+	}
+}
+
+func TestNewStringPropertyValue(t *testing.T) {
+	for _, s := range []string{"", "plain", `with "quotes"`, "{{ .bitrate }}"} {
+		p := NewStringPropertyValue(s)
+		if got, ok := p.GetString(); !ok || got != s {
+			t.Errorf("Expected string %q, got %q (%v)", s, got, ok)
+		}
+	}
+}
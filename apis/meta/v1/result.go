@@ -0,0 +1,93 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// resultObjectSuffix is appended to the key of an output to name its job result.
+const resultObjectSuffix = ".result.json"
+
+// ExitReason represents the reason a pipeline run ended.
+type ExitReason string
+
+const (
+	// ExitReasonFinished means the pipeline reached the end of its input.
+	ExitReasonFinished ExitReason = "Finished"
+	// ExitReasonError means the pipeline stopped on an error.
+	ExitReasonError ExitReason = "Error"
+	// ExitReasonTerminated means the pipeline was stopped by a signal before it finished.
+	ExitReasonTerminated ExitReason = "Terminated"
+)
+
+// JobResult is the report produced by the runner at the end of a pipeline run.
+type JobResult struct {
+	// The reason the run ended.
+	ExitReason ExitReason `json:"exitReason"`
+	// The error the pipeline stopped on, if any.
+	Error string `json:"error,omitempty"`
+	// How long the pipeline was running.
+	ProcessingTime metav1.Duration `json:"processingTime"`
+	// The source object of the run.
+	Source *ObjectResult `json:"src,omitempty"`
+	// The output objects of the run.
+	Outputs []*ObjectResult `json:"outputs,omitempty"`
+	// The tags found in the streams of the source, such as codecs, bitrates and titles.
+	Tags map[string]string `json:"tags,omitempty"`
+	// The position of the pipeline when the run ended.
+	Position *metav1.Duration `json:"position,omitempty"`
+	// The duration of the source, if it could be determined.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ObjectResult describes an object read or written during a pipeline run.
+type ObjectResult struct {
+	// The name of the object.
+	Name string `json:"name"`
+	// The bucket of the object.
+	Bucket string `json:"bucket,omitempty"`
+	// The size of the object in bytes. Omitted if the object could not be found.
+	Size *int64 `json:"size,omitempty"`
+	// The caps negotiated for the output. Only applies to outputs.
+	Caps string `json:"caps,omitempty"`
+	// The contents of the object found by discovery. Only applies to the source.
+	Media *MediaInfo `json:"media,omitempty"`
+}
+
+// ResultObjectName returns the name of the object a job result is written to next to the given
+// output object.
+func ResultObjectName(outputKey string) string {
+	return outputKey + resultObjectSuffix
+}
+
+// IsSidecarObject returns true if the given key is a job result or discovered metadata written next
+// to an output, rather than an output itself.
+func IsSidecarObject(key string) bool {
+	return strings.HasSuffix(key, resultObjectSuffix) || strings.HasSuffix(key, metadataObjectSuffix)
+}
+
+// String returns a one-line summary of the result.
+func (r *JobResult) String() string {
+	if r.ExitReason == ExitReasonError && r.Error != "" {
+		return fmt.Sprintf("The pipeline failed after %s: %s", r.ProcessingTime.Duration, r.Error)
+	}
+	return fmt.Sprintf("The pipeline exited with reason %s after %s and wrote %d outputs", r.ExitReason, r.ProcessingTime.Duration, len(r.Outputs))
+}
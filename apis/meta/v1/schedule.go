@@ -0,0 +1,79 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScheduleConfig is used to process the objects in a source on a schedule, instead of as
+// they are created.
+type ScheduleConfig struct {
+	// A cron expression for when to process the source, e.g. "0 2 * * *" to run nightly at 2 AM.
+	// Descriptors such as "@daily" and "@every 6h" are also accepted. Times are in UTC unless the
+	// expression is prefixed with a "CRON_TZ=" location. An invalid expression is reported in a
+	// Scheduled condition on the pipeline.
+	// +kubebuilder:validation:MinLength=1
+	Cron string `json:"cron"`
+	// Only process objects last modified within this duration before each run. When omitted, objects
+	// modified since the previous run are processed, or since the schedule was set up on the first run.
+	ModifiedWithin *metav1.Duration `json:"modifiedWithin,omitempty"`
+	// A regular expression that objects must match to be processed. The `exclude` expression of the
+	// src config is also applied.
+	Include string `json:"include,omitempty"`
+}
+
+// Parse parses the cron expression for this schedule.
+func (s *ScheduleConfig) Parse() (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cron expression %q: %s", s.Cron, err.Error())
+	}
+	return schedule, nil
+}
+
+// GetIncludeRegex returns the regex to use for including objects, or nil if not present
+// or any error.
+func (s *ScheduleConfig) GetIncludeRegex() *regexp.Regexp {
+	if s.Include == "" {
+		return nil
+	}
+	re, err := regexp.Compile(s.Include)
+	if err != nil {
+		fmt.Println("Failed to compile include regex", s.Include, "error:", err)
+		return nil
+	}
+	return re
+}
+
+// ScheduleStatus reports the results of scheduled processing.
+type ScheduleStatus struct {
+	// The time the source was last processed.
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+	// The time the source will next be processed.
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+	// The number of objects that matched the filters during the last run.
+	LastRunObjects int32 `json:"lastRunObjects"`
+	// The number of jobs created during the last run.
+	LastRunJobs int32 `json:"lastRunJobs"`
+	// The number of objects that jobs could not be created for during the last run.
+	LastRunErrors int32 `json:"lastRunErrors"`
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// TemplateReference refers to a PipelineTemplate and the values for its parameters.
+type TemplateReference struct {
+	// The name of the PipelineTemplate in the same namespace.
+	Name string `json:"name"`
+	// Values for the parameters declared by the template. Parameters that are omitted use
+	// their default values.
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// TemplateParameter declares a parameter of a PipelineTemplate.
+type TemplateParameter struct {
+	// The name of the parameter. Parameters are referenced in element names and property values
+	// as `{{ .name }}`.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	Name string `json:"name"`
+	// A description of the parameter.
+	Description string `json:"description,omitempty"`
+	// The value to use when the parameter is not given by a pipeline. Parameters without a
+	// default are required.
+	Default *string `json:"default,omitempty"`
+}
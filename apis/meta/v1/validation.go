@@ -0,0 +1,85 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationConfig represents a configuration for validating a pipeline before it processes
+// any objects.
+type ValidationConfig struct {
+	// The key of an object in the src bucket to preroll the pipeline against. When omitted, the
+	// pipeline is only built and brought to the READY state without reading a source.
+	SampleKey string `json:"sampleKey,omitempty"`
+}
+
+// ValidationReport is the report produced by the runner when validating a pipeline.
+type ValidationReport struct {
+	// Whether the pipeline is valid.
+	Valid bool `json:"valid"`
+	// The state the pipeline reached during validation.
+	State string `json:"state,omitempty"`
+	// Whether the pipeline was only brought to READY because there was no source to preroll it
+	// against. Links of dynamically added pads and caps negotiation are not checked in this case.
+	Partial bool `json:"partial,omitempty"`
+	// The problems found with the pipeline.
+	Issues []*ValidationIssue `json:"issues,omitempty"`
+}
+
+// ValidationIssue is a problem found while validating a pipeline.
+type ValidationIssue struct {
+	// The element the issue was found on, if any.
+	Element string `json:"element,omitempty"`
+	// The property the issue was found on, if any.
+	Property string `json:"property,omitempty"`
+	// A description of the issue.
+	Message string `json:"message"`
+}
+
+// AddIssue adds an issue to the report and marks it invalid.
+func (r *ValidationReport) AddIssue(element, property, message string) {
+	r.Valid = false
+	r.Issues = append(r.Issues, &ValidationIssue{Element: element, Property: property, Message: message})
+}
+
+// String returns a one-line summary of the report.
+func (r *ValidationReport) String() string {
+	if r.Valid && r.Partial {
+		return fmt.Sprintf("The pipeline reached the %s state, but was not prerolled against a source, so dynamic pads and caps negotiation were not checked", r.State)
+	}
+	if r.Valid {
+		return fmt.Sprintf("The pipeline is valid and reached the %s state", r.State)
+	}
+	issues := make([]string, len(r.Issues))
+	for idx, issue := range r.Issues {
+		issues[idx] = issue.String()
+	}
+	return fmt.Sprintf("The pipeline is invalid: %s", strings.Join(issues, "; "))
+}
+
+// String returns a description of the issue including the element and property.
+func (i *ValidationIssue) String() string {
+	switch {
+	case i.Element != "" && i.Property != "":
+		return fmt.Sprintf("%s.%s: %s", i.Element, i.Property, i.Message)
+	case i.Element != "":
+		return fmt.Sprintf("%s: %s", i.Element, i.Message)
+	}
+	return i.Message
+}
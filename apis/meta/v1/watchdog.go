@@ -0,0 +1,56 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultShutdownTimeout is the default time given to a pipeline to reach the NULL state once it
+// has finished.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// WatchdogConfig represents a configuration for detecting pipelines that have stopped making
+// progress, such as when a branch of a demuxer without a queue deadlocks the pipeline.
+type WatchdogConfig struct {
+	// The time the pipeline may go without its position advancing or any element passing a buffer
+	// downstream before the run is failed. Stall detection stops once the pipeline starts shutting
+	// down, and is disabled when unset.
+	StallTimeout *metav1.Duration `json:"stallTimeout,omitempty"`
+	// The time given to the pipeline to reach the NULL state once it has finished, after which the
+	// run is failed. Defaults to 30s.
+	ShutdownTimeout *metav1.Duration `json:"shutdownTimeout,omitempty"`
+}
+
+// GetStallTimeout returns the time the position may go without advancing, or 0 if stall detection
+// is disabled.
+func (p *PipelineConfig) GetStallTimeout() time.Duration {
+	if p.Watchdog == nil || p.Watchdog.StallTimeout == nil {
+		return 0
+	}
+	return p.Watchdog.StallTimeout.Duration
+}
+
+// GetShutdownTimeout returns the time given to the pipeline to reach the NULL state.
+func (p *PipelineConfig) GetShutdownTimeout() time.Duration {
+	if p.Watchdog == nil || p.Watchdog.ShutdownTimeout == nil {
+		return DefaultShutdownTimeout
+	}
+	return p.Watchdog.ShutdownTimeout.Duration
+}
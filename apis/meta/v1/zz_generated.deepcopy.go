@@ -22,6 +22,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -32,6 +33,11 @@ func (in *DebugConfig) DeepCopyInto(out *DebugConfig) {
 		*out = new(DotConfig)
 		**out = **in
 	}
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(SourceSinkConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DebugConfig.
@@ -44,6 +50,26 @@ func (in *DebugConfig) DeepCopy() *DebugConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryConfig) DeepCopyInto(out *DiscoveryConfig) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryConfig.
+func (in *DiscoveryConfig) DeepCopy() *DiscoveryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DotConfig) DeepCopyInto(out *DotConfig) {
 	*out = *in
@@ -64,9 +90,9 @@ func (in *ElementConfig) DeepCopyInto(out *ElementConfig) {
 	*out = *in
 	if in.Properties != nil {
 		in, out := &in.Properties, &out.Properties
-		*out = make(map[string]string, len(*in))
+		*out = make(map[string]PropertyValue, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 }
@@ -81,6 +107,26 @@ func (in *ElementConfig) DeepCopy() *ElementConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionConfig) DeepCopyInto(out *EncryptionConfig) {
+	*out = *in
+	if in.CustomerKeySecret != nil {
+		in, out := &in.CustomerKeySecret, &out.CustomerKeySecret
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionConfig.
+func (in *EncryptionConfig) DeepCopy() *EncryptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GstElementConfig) DeepCopyInto(out *GstElementConfig) {
 	*out = *in
@@ -91,15 +137,20 @@ func (in *GstElementConfig) DeepCopyInto(out *GstElementConfig) {
 	}
 	if in.peers != nil {
 		in, out := &in.peers, &out.peers
-		*out = make([]*GstElementConfig, len(*in))
+		*out = make([]*GstElementPeer, len(*in))
 		for i := range *in {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
-				*out = new(GstElementConfig)
+				*out = new(GstElementPeer)
 				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
+	if in.padTargets != nil {
+		in, out := &in.padTargets, &out.padTargets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GstElementConfig.
@@ -112,6 +163,26 @@ func (in *GstElementConfig) DeepCopy() *GstElementConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GstElementPeer) DeepCopyInto(out *GstElementPeer) {
+	*out = *in
+	if in.GstElementConfig != nil {
+		in, out := &in.GstElementConfig, &out.GstElementConfig
+		*out = new(GstElementConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GstElementPeer.
+func (in *GstElementPeer) DeepCopy() *GstElementPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(GstElementPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in GstLaunchConfig) DeepCopyInto(out *GstLaunchConfig) {
 	{
@@ -137,9 +208,153 @@ func (in GstLaunchConfig) DeepCopy() GstLaunchConfig {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobResult) DeepCopyInto(out *JobResult) {
+	*out = *in
+	out.ProcessingTime = in.ProcessingTime
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ObjectResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]*ObjectResult, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ObjectResult)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Position != nil {
+		in, out := &in.Position, &out.Position
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobResult.
+func (in *JobResult) DeepCopy() *JobResult {
+	if in == nil {
+		return nil
+	}
+	out := new(JobResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LiveSourceConfig) DeepCopyInto(out *LiveSourceConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiveSourceConfig.
+func (in *LiveSourceConfig) DeepCopy() *LiveSourceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LiveSourceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MediaInfo) DeepCopyInto(out *MediaInfo) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Streams != nil {
+		in, out := &in.Streams, &out.Streams
+		*out = make([]*MediaStream, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(MediaStream)
+				**out = **in
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MediaInfo.
+func (in *MediaInfo) DeepCopy() *MediaInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(MediaInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MediaStream) DeepCopyInto(out *MediaStream) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MediaStream.
+func (in *MediaStream) DeepCopy() *MediaStream {
+	if in == nil {
+		return nil
+	}
+	out := new(MediaStream)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsConfig.
+func (in *MetricsConfig) DeepCopy() *MetricsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MinIOConfig) DeepCopyInto(out *MinIOConfig) {
 	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(EncryptionConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CredentialsSecret != nil {
 		in, out := &in.CredentialsSecret, &out.CredentialsSecret
 		*out = new(corev1.LocalObjectReference)
@@ -177,6 +392,31 @@ func (in *Object) DeepCopy() *Object {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectResult) DeepCopyInto(out *ObjectResult) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Media != nil {
+		in, out := &in.Media, &out.Media
+		*out = new(MediaInfo)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectResult.
+func (in *ObjectResult) DeepCopy() *ObjectResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineConfig) DeepCopyInto(out *PipelineConfig) {
 	*out = *in
@@ -196,7 +436,32 @@ func (in *PipelineConfig) DeepCopyInto(out *PipelineConfig) {
 			}
 		}
 	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(TemplateReference)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.TerminationGracePeriod != nil {
+		in, out := &in.TerminationGracePeriod, &out.TerminationGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Discover != nil {
+		in, out := &in.Discover, &out.Discover
+		*out = new(DiscoveryConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(MetricsConfig)
+		**out = **in
+	}
+	if in.Watchdog != nil {
+		in, out := &in.Watchdog, &out.Watchdog
+		*out = new(WatchdogConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineConfig.
@@ -224,6 +489,89 @@ func (in *PipelineReference) DeepCopy() *PipelineReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropertyValue) DeepCopyInto(out *PropertyValue) {
+	*out = *in
+	if in.Raw != nil {
+		in, out := &in.Raw, &out.Raw
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropertyValue.
+func (in *PropertyValue) DeepCopy() *PropertyValue {
+	if in == nil {
+		return nil
+	}
+	out := new(PropertyValue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleConfig) DeepCopyInto(out *ScheduleConfig) {
+	*out = *in
+	if in.ModifiedWithin != nil {
+		in, out := &in.ModifiedWithin, &out.ModifiedWithin
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleConfig.
+func (in *ScheduleConfig) DeepCopy() *ScheduleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleStatus) DeepCopyInto(out *ScheduleStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		in, out := &in.NextRunTime, &out.NextRunTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleStatus.
+func (in *ScheduleStatus) DeepCopy() *ScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SegmentConfig) DeepCopyInto(out *SegmentConfig) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SegmentConfig.
+func (in *SegmentConfig) DeepCopy() *SegmentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SegmentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceSinkConfig) DeepCopyInto(out *SourceSinkConfig) {
 	*out = *in
@@ -243,3 +591,126 @@ func (in *SourceSinkConfig) DeepCopy() *SourceSinkConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateParameter) DeepCopyInto(out *TemplateParameter) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateParameter.
+func (in *TemplateParameter) DeepCopy() *TemplateParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateReference) DeepCopyInto(out *TemplateReference) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateReference.
+func (in *TemplateReference) DeepCopy() *TemplateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationConfig) DeepCopyInto(out *ValidationConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationConfig.
+func (in *ValidationConfig) DeepCopy() *ValidationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationIssue) DeepCopyInto(out *ValidationIssue) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationIssue.
+func (in *ValidationIssue) DeepCopy() *ValidationIssue {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationIssue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationReport) DeepCopyInto(out *ValidationReport) {
+	*out = *in
+	if in.Issues != nil {
+		in, out := &in.Issues, &out.Issues
+		*out = make([]*ValidationIssue, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ValidationIssue)
+				**out = **in
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationReport.
+func (in *ValidationReport) DeepCopy() *ValidationReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WatchdogConfig) DeepCopyInto(out *WatchdogConfig) {
+	*out = *in
+	if in.StallTimeout != nil {
+		in, out := &in.StallTimeout, &out.StallTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ShutdownTimeout != nil {
+		in, out := &in.ShutdownTimeout, &out.ShutdownTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WatchdogConfig.
+func (in *WatchdogConfig) DeepCopy() *WatchdogConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WatchdogConfig)
+	in.DeepCopyInto(out)
+	return out
+}
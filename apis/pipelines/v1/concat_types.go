@@ -0,0 +1,98 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PipelineConcat represents a concat pipeline
+	PipelineConcat pipelinesmeta.PipelineKind = "Concat"
+)
+
+// DefaultManifestPattern is the default expression used to match manifest objects for a
+// concat pipeline.
+const DefaultManifestPattern = `\.(json|txt)$`
+
+// DefaultOutputExtension is the default extension of the objects produced by a concat pipeline.
+const DefaultOutputExtension = ".mp4"
+
+// ConcatSpec defines the desired state of Concat. Objects placed in the src prefix that match
+// the manifest pattern are read as a list of other object keys. Each of those objects is decoded
+// and concatenated, in the order they are listed, into a single output.
+//
+// A manifest can either be a JSON array of keys, a JSON object with the keys in an `objects`
+// field, or plain text with one key per line. Blank lines and lines starting with `#` are ignored.
+// Keys are resolved relative to the directory of the manifest, unless they begin with a `/`, in which
+// case they are resolved from the root of the bucket.
+type ConcatSpec struct {
+	// Global configurations to apply when omitted from the src or sink configurations.
+	Globals *pipelinesmeta.SourceSinkConfig `json:"globals,omitempty"`
+	// Configurations for the manifest objects and the objects they reference.
+	Src *pipelinesmeta.SourceSinkConfig `json:"src"`
+	// Configurations for sink objects from the pipeline. The `SrcName` passed to the template
+	// is the base name of the manifest and `SrcExt` is the `outputExtension`. When the key is not
+	// a template, outputs are named the same way inside the key prefix.
+	Sink *pipelinesmeta.SourceSinkConfig `json:"sink"`
+	// The extension, including the leading dot, of the objects produced by the pipeline.
+	// Defaults to `.mp4`.
+	// +kubebuilder:validation:Pattern=`^\.[a-zA-Z0-9]+$`
+	OutputExtension string `json:"outputExtension,omitempty"`
+	// A regular expression that keys in the src prefix must match to be treated as a manifest.
+	// All other objects are ignored. Defaults to `\.(json|txt)$`. An expression that does not
+	// compile is reported in the status and no manifests are processed until it is fixed.
+	ManifestPattern string `json:"manifestPattern,omitempty"`
+	// The configuration for the processing pipeline. The decoded streams of the referenced objects
+	// are available via a goto to `concat-video` and `concat-audio`. Streams that are not referenced
+	// in the pipeline are discarded.
+	Pipeline *pipelinesmeta.PipelineConfig `json:"pipeline"`
+}
+
+// ConcatStatus defines the observed state of Concat
+type ConcatStatus struct {
+	// Conditions represent the latest available observations of a concat's state
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Status",type="string",priority=1,JSONPath=`.status.conditions[-1].message`
+
+// Concat is the Schema for the concats API
+type Concat struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConcatSpec   `json:"spec,omitempty"`
+	Status ConcatStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConcatList contains a list of Concat
+type ConcatList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Concat `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Concat{}, &ConcatList{})
+}
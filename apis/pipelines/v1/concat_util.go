@@ -0,0 +1,149 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// OwnerReferences returns the OwnerReferences for this pipeline to be placed on jobs.
+func (c *Concat) OwnerReferences() []metav1.OwnerReference { return ownerReferences(c) }
+
+// GetPipelineKind satisfies the Pipeline interface and returns the type of the pipeline.
+func (c *Concat) GetPipelineKind() pipelinesmeta.PipelineKind {
+	return PipelineConcat
+}
+
+// GetPipelineConfig returns the PipelineConfig.
+func (c *Concat) GetPipelineConfig() *pipelinesmeta.PipelineConfig { return c.Spec.Pipeline }
+
+// GetSrcConfig will return the src config for this pipeline merged with the globals.
+func (c *Concat) GetSrcConfig() *pipelinesmeta.SourceSinkConfig {
+	return mergeConfigs(c.Spec.Globals, c.Spec.Src)
+}
+
+// GetSinkConfig will return the sink config for this pipeline merged with the globals.
+func (c *Concat) GetSinkConfig() *pipelinesmeta.SourceSinkConfig {
+	return mergeConfigs(c.Spec.Globals, c.Spec.Sink)
+}
+
+// GetSinkObjects returns the sink objects for a pipeline. The output is named after the base name
+// of the manifest with the output extension, since the extension of the manifest does not describe
+// the concatenated media.
+func (c *Concat) GetSinkObjects(srcKey string) []*pipelinesmeta.Object {
+	name := path.Base(strings.TrimSuffix(srcKey, path.Ext(srcKey)))
+	return []*pipelinesmeta.Object{
+		{
+			Name:       c.GetSinkConfig().MinIO.GetOutputKey(name, c.GetOutputExtension()),
+			Config:     c.GetSinkConfig(),
+			StreamType: pipelinesmeta.StreamTypeAll,
+		},
+	}
+}
+
+// GetOutputExtension returns the extension of the output objects.
+func (c *Concat) GetOutputExtension() string {
+	if c.Spec.OutputExtension != "" {
+		return c.Spec.OutputExtension
+	}
+	return DefaultOutputExtension
+}
+
+// GetManifestRegex returns the regex used to match manifest objects, or an error if the configured
+// expression does not compile.
+func (c *Concat) GetManifestRegex() (*regexp.Regexp, error) {
+	if c.Spec.ManifestPattern == "" {
+		return regexp.MustCompile(DefaultManifestPattern), nil
+	}
+	return regexp.Compile(c.Spec.ManifestPattern)
+}
+
+// IsManifest returns true if the given key should be treated as a manifest. No key is treated
+// as a manifest while the manifest pattern is invalid.
+func (c *Concat) IsManifest(key string) bool {
+	re, err := c.GetManifestRegex()
+	if err != nil {
+		return false
+	}
+	return re.MatchString(key)
+}
+
+// GetInputObjects parses the given manifest and returns the objects it references
+// in the order they should be concatenated.
+func (c *Concat) GetInputObjects(manifestKey string, manifest []byte) ([]*pipelinesmeta.Object, error) {
+	keys, err := parseManifest(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse manifest %s: %s", manifestKey, err.Error())
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Manifest %s does not reference any objects", manifestKey)
+	}
+	srcConfig := c.GetSrcConfig()
+	objs := make([]*pipelinesmeta.Object, len(keys))
+	for idx, key := range keys {
+		objs[idx] = &pipelinesmeta.Object{
+			Name:   resolveManifestKey(manifestKey, key),
+			Config: srcConfig,
+		}
+	}
+	return objs, nil
+}
+
+func parseManifest(manifest []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(manifest)
+	if len(trimmed) == 0 {
+		return nil, errors.New("manifest is empty")
+	}
+	switch trimmed[0] {
+	case '[':
+		var keys []string
+		return keys, json.Unmarshal(trimmed, &keys)
+	case '{':
+		var obj struct {
+			Objects []string `json:"objects"`
+		}
+		return obj.Objects, json.Unmarshal(trimmed, &obj)
+	}
+	keys := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	return keys, scanner.Err()
+}
+
+func resolveManifestKey(manifestKey, key string) string {
+	if strings.HasPrefix(key, "/") {
+		return strings.TrimPrefix(path.Clean(key), "/")
+	}
+	return path.Join(path.Dir(manifestKey), key)
+}
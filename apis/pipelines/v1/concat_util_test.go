@@ -0,0 +1,217 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+func newTestConcat(sinkKey, outputExtension, manifestPattern string) *Concat {
+	return &Concat{
+		Spec: ConcatSpec{
+			Src: &pipelinesmeta.SourceSinkConfig{
+				MinIO: &pipelinesmeta.MinIOConfig{Bucket: "clips", Prefix: "clips/"},
+			},
+			Sink: &pipelinesmeta.SourceSinkConfig{
+				MinIO: &pipelinesmeta.MinIOConfig{Bucket: "joined", Prefix: sinkKey},
+			},
+			OutputExtension: outputExtension,
+			ManifestPattern: manifestPattern,
+		},
+	}
+}
+
+func TestConcatGetInputObjects(t *testing.T) {
+	tests := []struct {
+		name        string
+		manifestKey string
+		manifest    string
+		expected    []string
+		expectErr   bool
+	}{
+		{
+			name:        "json array",
+			manifestKey: "clips/day1/clips.json",
+			manifest:    `["a.mp4", "b.mp4"]`,
+			expected:    []string{"clips/day1/a.mp4", "clips/day1/b.mp4"},
+		},
+		{
+			name:        "json object",
+			manifestKey: "clips/clips.json",
+			manifest:    ` {"objects": ["intro.mp4", "sub/main.mp4"]}`,
+			expected:    []string{"clips/intro.mp4", "clips/sub/main.mp4"},
+		},
+		{
+			name:        "plain text with comments and blank lines",
+			manifestKey: "clips/list.txt",
+			manifest:    "# opening\nintro.mp4\n\n  main.mp4  \n# closing\noutro.mp4\n",
+			expected:    []string{"clips/intro.mp4", "clips/main.mp4", "clips/outro.mp4"},
+		},
+		{
+			name:        "absolute keys resolve from the bucket root",
+			manifestKey: "clips/day1/list.txt",
+			manifest:    "/shared/intro.mp4\n/shared/../outro.mp4\n../day0/main.mp4",
+			expected:    []string{"shared/intro.mp4", "outro.mp4", "clips/day0/main.mp4"},
+		},
+		{
+			name:        "manifest at the bucket root",
+			manifestKey: "list.txt",
+			manifest:    "a.mp4",
+			expected:    []string{"a.mp4"},
+		},
+		{
+			name:        "empty manifest",
+			manifestKey: "clips/list.txt",
+			manifest:    "  \n",
+			expectErr:   true,
+		},
+		{
+			name:        "manifest with only comments",
+			manifestKey: "clips/list.txt",
+			manifest:    "# nothing yet",
+			expectErr:   true,
+		},
+		{
+			name:        "empty json array",
+			manifestKey: "clips/clips.json",
+			manifest:    "[]",
+			expectErr:   true,
+		},
+		{
+			name:        "malformed json",
+			manifestKey: "clips/clips.json",
+			manifest:    `["a.mp4",`,
+			expectErr:   true,
+		},
+	}
+
+	concat := newTestConcat("", "", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := concat.GetInputObjects(tt.manifestKey, []byte(tt.manifest))
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got objects %v", objs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := make([]string, len(objs))
+			for idx, obj := range objs {
+				keys[idx] = obj.Name
+				if obj.Config == nil || obj.Config.MinIO.GetBucket() != "clips" {
+					t.Errorf("Expected object %s to use the src config, got %+v", obj.Name, obj.Config)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.expected) {
+				t.Errorf("Expected keys %v, got %v", tt.expected, keys)
+			}
+		})
+	}
+}
+
+func TestConcatGetSinkObjects(t *testing.T) {
+	tests := []struct {
+		name            string
+		sinkKey         string
+		outputExtension string
+		manifestKey     string
+		expected        string
+	}{
+		{
+			name:        "prefix with the default extension",
+			sinkKey:     "joined/",
+			manifestKey: "clips/day1/clips.json",
+			expected:    "joined/clips.mp4",
+		},
+		{
+			name:            "prefix with a configured extension",
+			sinkKey:         "joined",
+			outputExtension: ".mkv",
+			manifestKey:     "clips/list.txt",
+			expected:        "joined/list.mkv",
+		},
+		{
+			name:        "empty key",
+			manifestKey: "clips/clips.json",
+			expected:    "clips.mp4",
+		},
+		{
+			name:            "template",
+			sinkKey:         "joined/{{ .SrcName }}-full{{ .SrcExt }}",
+			outputExtension: ".webm",
+			manifestKey:     "clips/clips.json",
+			expected:        "joined/clips-full.webm",
+		},
+		{
+			name:        "template with a literal extension",
+			sinkKey:     "joined/{{ .SrcName | upper }}.mp4",
+			manifestKey: "clips/clips.txt",
+			expected:    "joined/CLIPS.mp4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			concat := newTestConcat(tt.sinkKey, tt.outputExtension, "")
+			sinks := concat.GetSinkObjects(tt.manifestKey)
+			if len(sinks) != 1 {
+				t.Fatalf("Expected a single sink object, got %d", len(sinks))
+			}
+			if sinks[0].Name != tt.expected {
+				t.Errorf("Expected sink object %q, got %q", tt.expected, sinks[0].Name)
+			}
+			if sinks[0].Config.MinIO.GetBucket() != "joined" {
+				t.Errorf("Expected the sink config, got %+v", sinks[0].Config)
+			}
+		})
+	}
+}
+
+func TestConcatManifestPattern(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		key       string
+		expected  bool
+		expectErr bool
+	}{
+		{name: "default pattern json", key: "clips/clips.json", expected: true},
+		{name: "default pattern txt", key: "clips/list.txt", expected: true},
+		{name: "default pattern media", key: "clips/a.mp4", expected: false},
+		{name: "configured pattern", pattern: `\.m3u$`, key: "clips/list.m3u", expected: true},
+		{name: "configured pattern replaces the default", pattern: `\.m3u$`, key: "clips/clips.json", expected: false},
+		{name: "invalid pattern", pattern: `\.(json$`, key: "clips/clips.json", expected: false, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			concat := newTestConcat("", "", tt.pattern)
+			if _, err := concat.GetManifestRegex(); (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if matched := concat.IsManifest(tt.key); matched != tt.expected {
+				t.Errorf("Expected IsManifest(%q) to be %v, got %v", tt.key, tt.expected, matched)
+			}
+		})
+	}
+}
@@ -43,12 +43,17 @@ type JobSpec struct {
 	Source *pipelinesmeta.Object `json:"src"`
 	// The output objects for the pipeline.
 	Sinks []*pipelinesmeta.Object `json:"sinks"`
+	// The input objects for pipelines that read more than one object, such as a Concat. When
+	// present, these are read in order and the source is the object that triggered the job.
+	Inputs []*pipelinesmeta.Object `json:"inputs,omitempty"`
 }
 
 // JobStatus defines the observed state of Job
 type JobStatus struct {
 	// Conditions represent the latest available observations of a job's state
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// The result reported by the pipeline once it has exited.
+	Result *pipelinesmeta.JobResult `json:"result,omitempty"`
 }
 
 // +kubebuilder:object:root=true
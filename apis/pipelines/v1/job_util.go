@@ -50,3 +50,21 @@ func (j *Job) GetSplitTransformPipeline(ctx context.Context, client client.Clien
 	var pipeline SplitTransform
 	return &pipeline, client.Get(ctx, nn, &pipeline)
 }
+
+// GetConcatPipeline returns the concat pipeline for this job spec.
+func (j *Job) GetConcatPipeline(ctx context.Context, client client.Client) (*Concat, error) {
+	nn := types.NamespacedName{
+		Name:      j.Spec.PipelineReference.Name,
+		Namespace: j.GetNamespace(),
+	}
+	var pipeline Concat
+	return &pipeline, client.Get(ctx, nn, &pipeline)
+}
+
+// GetState returns the most recent state observed for this job.
+func (j *Job) GetState() JobState {
+	if len(j.Status.Conditions) == 0 {
+		return JobPending
+	}
+	return JobState(j.Status.Conditions[len(j.Status.Conditions)-1].Type)
+}
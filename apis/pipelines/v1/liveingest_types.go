@@ -0,0 +1,95 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PipelineLiveIngest represents a live ingest pipeline
+	PipelineLiveIngest pipelinesmeta.PipelineKind = "LiveIngest"
+)
+
+// LiveConnectionState represents the state of the connection to a live stream.
+type LiveConnectionState string
+
+const (
+	// LiveConnecting means the pipeline is waiting to receive data from the stream.
+	LiveConnecting LiveConnectionState = "Connecting"
+	// LiveConnected means data from the stream is being recorded.
+	LiveConnected LiveConnectionState = "Connected"
+	// LiveReconnecting means the pipeline lost the stream or failed, and is being restarted.
+	LiveReconnecting LiveConnectionState = "Reconnecting"
+)
+
+// LiveIngestSpec defines the desired state of LiveIngest
+type LiveIngestSpec struct {
+	// Configurations for the network stream to ingest.
+	Src *pipelinesmeta.LiveSourceConfig `json:"src"`
+	// Configurations for the recorded segments. The `key` is a template executed for each segment
+	// with "Time" set to the time the segment started and "Index" set to the number of the segment,
+	// e.g. `cam1/{{ .Time.Format "2006/01/02/15-04-05" }}.ts`.
+	Sink *pipelinesmeta.SourceSinkConfig `json:"sink"`
+	// Configurations for how the stream is split into segments.
+	Segments *pipelinesmeta.SegmentConfig `json:"segments,omitempty"`
+	// The configuration for the processing pipeline. The pipeline receives the stream as it is provided
+	// by the source, and must produce encoded streams for the segment muxer. The `video-out` and `audio-out`
+	// linkto aliases can be used when recording both video and audio.
+	Pipeline *pipelinesmeta.PipelineConfig `json:"pipeline"`
+}
+
+// LiveIngestStatus defines the observed state of LiveIngest
+type LiveIngestStatus struct {
+	// The state of the connection to the stream.
+	ConnectionState LiveConnectionState `json:"connectionState,omitempty"`
+	// The number of times the pipeline has been restarted.
+	Restarts int32 `json:"restarts,omitempty"`
+	// Conditions represent the latest available observations of a live ingest's state
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Source",type="string",JSONPath=`.spec.src.uri`
+// +kubebuilder:printcolumn:name="Connection",type="string",JSONPath=`.status.connectionState`
+// +kubebuilder:printcolumn:name="Restarts",type="integer",JSONPath=`.status.restarts`
+// +kubebuilder:printcolumn:name="Status",type="string",priority=1,JSONPath=`.status.conditions[-1].message`
+
+// LiveIngest is the Schema for the liveingests API
+type LiveIngest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LiveIngestSpec   `json:"spec,omitempty"`
+	Status LiveIngestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// LiveIngestList contains a list of LiveIngest
+type LiveIngestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LiveIngest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LiveIngest{}, &LiveIngestList{})
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// OwnerReferences returns the OwnerReferences for this pipeline to be placed on deployments.
+func (l *LiveIngest) OwnerReferences() []metav1.OwnerReference { return ownerReferences(l) }
+
+// GetPipelineKind returns the type of the pipeline.
+func (l *LiveIngest) GetPipelineKind() pipelinesmeta.PipelineKind { return PipelineLiveIngest }
+
+// GetPipelineConfig returns the PipelineConfig.
+func (l *LiveIngest) GetPipelineConfig() *pipelinesmeta.PipelineConfig { return l.Spec.Pipeline }
+
+// GetSinkConfig returns the sink config for the recorded segments.
+func (l *LiveIngest) GetSinkConfig() *pipelinesmeta.SourceSinkConfig { return l.Spec.Sink }
+
+// GetSinkObjects returns the sink objects for the pipeline. The name of the object is left empty
+// since the key of each segment is computed while recording.
+func (l *LiveIngest) GetSinkObjects() []*pipelinesmeta.Object {
+	return []*pipelinesmeta.Object{
+		{
+			Config:     l.GetSinkConfig(),
+			StreamType: pipelinesmeta.StreamTypeAll,
+		},
+	}
+}
+
+// GetDeploymentLabels returns the labels to apply to the deployment for this pipeline.
+func (l *LiveIngest) GetDeploymentLabels() map[string]string {
+	return map[string]string{
+		pipelinesmeta.JobPipelineLabel:     l.GetName(),
+		pipelinesmeta.JobPipelineKindLabel: string(PipelineLiveIngest),
+	}
+}
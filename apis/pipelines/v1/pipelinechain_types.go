@@ -0,0 +1,92 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChainStage declares a single stage of a PipelineChain.
+type ChainStage struct {
+	// A name for the stage used when reporting progress. Defaults to the name of the referenced
+	// Transform.
+	Name string `json:"name,omitempty"`
+	// The name of the Transform in the same namespace that is run for this stage.
+	TransformRef string `json:"transformRef"`
+}
+
+// PipelineChainSpec defines the desired state of PipelineChain
+type PipelineChainSpec struct {
+	// The stages of the chain in the order they are run. Jobs for the first stage are created by the
+	// referenced Transform as objects arrive at its source, only jobs created after the chain are picked up.
+	// When a job for any stage finishes successfully, a job for the next stage is created with the outputs of
+	// the previous job as its source. Transforms used as later stages must omit their `src` configuration,
+	// otherwise they would process every object a second time from their own bucket watch. The chain reports
+	// an error and creates no jobs while any of them has one.
+	// +kubebuilder:validation:MinItems=2
+	Stages []*ChainStage `json:"stages"`
+}
+
+// ChainStageStatus reports the progress of the jobs for a single stage of a PipelineChain.
+type ChainStageStatus struct {
+	// The name of the stage.
+	Name string `json:"name"`
+	// The number of jobs for this stage waiting to be started.
+	Pending int32 `json:"pending"`
+	// The number of jobs for this stage currently running.
+	InProgress int32 `json:"inProgress"`
+	// The number of jobs for this stage that completed successfully.
+	Finished int32 `json:"finished"`
+	// The number of jobs for this stage that failed.
+	Failed int32 `json:"failed"`
+}
+
+// PipelineChainStatus defines the observed state of PipelineChain
+type PipelineChainStatus struct {
+	// The progress of each stage in the chain.
+	Stages []ChainStageStatus `json:"stages,omitempty"`
+	// Conditions represent the latest available observations of a chain's state
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Stages",type="string",JSONPath=`.spec.stages[*].transformRef`
+// +kubebuilder:printcolumn:name="Status",type="string",priority=1,JSONPath=`.status.conditions[-1].message`
+
+// PipelineChain is the Schema for the pipelinechains API
+type PipelineChain struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PipelineChainSpec   `json:"spec,omitempty"`
+	Status PipelineChainStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PipelineChainList contains a list of PipelineChain
+type PipelineChainList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PipelineChain `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PipelineChain{}, &PipelineChainList{})
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// OwnerReferences returns the OwnerReferences for this chain to be placed on jobs.
+func (c *PipelineChain) OwnerReferences() []metav1.OwnerReference { return ownerReferences(c) }
+
+// GetStageName returns the name of the stage at the given index.
+func (c *PipelineChain) GetStageName(idx int) string {
+	stage := c.Spec.Stages[idx]
+	if stage.Name != "" {
+		return stage.Name
+	}
+	return stage.TransformRef
+}
+
+// StageForJob returns the index of the stage the given job is running for this chain. The
+// second return value is false if the job does not belong to this chain. Jobs for the first stage
+// are created by the Transform itself, so any job for that Transform not created by a chain is
+// considered part of the first stage, as long as it was created after the chain. Jobs the
+// Transform ran before the chain existed are not picked up.
+func (c *PipelineChain) StageForJob(job *Job) (int, bool) {
+	labels := job.GetLabels()
+	if chain, ok := labels[pipelinesmeta.JobChainLabel]; ok {
+		if chain != c.GetName() {
+			return 0, false
+		}
+		stage, err := strconv.Atoi(labels[pipelinesmeta.JobChainStageLabel])
+		if err != nil || stage < 0 || stage >= len(c.Spec.Stages) {
+			return 0, false
+		}
+		return stage, true
+	}
+	if len(c.Spec.Stages) == 0 || job.GetPipelineKind() != PipelineTransform {
+		return 0, false
+	}
+	if job.Spec.PipelineReference.Name != c.Spec.Stages[0].TransformRef {
+		return 0, false
+	}
+	created := job.GetCreationTimestamp()
+	return 0, !created.Before(&c.CreationTimestamp)
+}
+
+// NewStageJobs returns the jobs to create for the given stage from the outputs of a job that
+// finished in the previous stage. The names of the jobs are derived from the parent so that
+// they are only ever created once.
+func (c *PipelineChain) NewStageJobs(stage int, parent *Job, transform *Transform) []*Job {
+	jobs := make([]*Job, 0)
+	for _, out := range parent.Spec.Sinks {
+		h := md5.New()
+		io.WriteString(h, parent.GetName()+"/"+out.Name)
+		labels := GetJobLabels(transform, out.Name)
+		labels[pipelinesmeta.JobChainLabel] = c.GetName()
+		labels[pipelinesmeta.JobChainStageLabel] = strconv.Itoa(stage)
+		if out.Config != nil && out.Config.MinIO != nil {
+			labels[pipelinesmeta.JobBucketLabel] = out.Config.MinIO.GetBucket()
+		}
+		jobs = append(jobs, &Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("%s-%d-%x", c.GetName(), stage, h.Sum(nil)[:5]),
+				Namespace:       c.GetNamespace(),
+				Labels:          labels,
+				OwnerReferences: c.OwnerReferences(),
+			},
+			Spec: JobSpec{
+				PipelineReference: pipelinesmeta.PipelineReference{
+					Name: transform.GetName(),
+					Kind: PipelineTransform,
+				},
+				Source: &pipelinesmeta.Object{
+					Name:   out.Name,
+					Config: out.Config,
+				},
+				Sinks: transform.GetSinkObjects(out.Name),
+			},
+		})
+	}
+	return jobs
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+func newTestChain(created time.Time) *PipelineChain {
+	return &PipelineChain{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "chain",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: PipelineChainSpec{
+			Stages: []*ChainStage{
+				{TransformRef: "transcode"},
+				{Name: "thumbnails", TransformRef: "thumbnail"},
+				{TransformRef: "package"},
+			},
+		},
+	}
+}
+
+func TestPipelineChainStageForJob(t *testing.T) {
+	created := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	chain := newTestChain(created)
+
+	newJob := func(transform string, kind pipelinesmeta.PipelineKind, created time.Time, labels map[string]string) *Job {
+		return &Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "job",
+				CreationTimestamp: metav1.NewTime(created),
+				Labels:            labels,
+			},
+			Spec: JobSpec{
+				PipelineReference: pipelinesmeta.PipelineReference{Name: transform, Kind: kind},
+			},
+		}
+	}
+	chainLabels := func(chain, stage string) map[string]string {
+		return map[string]string{
+			pipelinesmeta.JobChainLabel:      chain,
+			pipelinesmeta.JobChainStageLabel: stage,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		job           *Job
+		expectedStage int
+		expectedOK    bool
+	}{
+		{
+			name:          "first stage job created after the chain",
+			job:           newJob("transcode", PipelineTransform, created.Add(time.Minute), nil),
+			expectedStage: 0,
+			expectedOK:    true,
+		},
+		{
+			name:          "first stage job created with the chain",
+			job:           newJob("transcode", PipelineTransform, created, nil),
+			expectedStage: 0,
+			expectedOK:    true,
+		},
+		{
+			name:       "first stage job created before the chain",
+			job:        newJob("transcode", PipelineTransform, created.Add(-time.Minute), nil),
+			expectedOK: false,
+		},
+		{
+			name:       "job for a later stage Transform not created by the chain",
+			job:        newJob("thumbnail", PipelineTransform, created.Add(time.Minute), nil),
+			expectedOK: false,
+		},
+		{
+			name:       "job for another kind of pipeline",
+			job:        newJob("transcode", PipelineSplitTransform, created.Add(time.Minute), nil),
+			expectedOK: false,
+		},
+		{
+			name:          "job created by the chain",
+			job:           newJob("thumbnail", PipelineTransform, created.Add(time.Minute), chainLabels("chain", "1")),
+			expectedStage: 1,
+			expectedOK:    true,
+		},
+		{
+			name:       "job created by another chain",
+			job:        newJob("transcode", PipelineTransform, created.Add(time.Minute), chainLabels("other", "1")),
+			expectedOK: false,
+		},
+		{
+			name:       "job with a stage out of range",
+			job:        newJob("package", PipelineTransform, created.Add(time.Minute), chainLabels("chain", "3")),
+			expectedOK: false,
+		},
+		{
+			name:       "job with an invalid stage",
+			job:        newJob("package", PipelineTransform, created.Add(time.Minute), chainLabels("chain", "last")),
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, ok := chain.StageForJob(tt.job)
+			if ok != tt.expectedOK {
+				t.Fatalf("Expected ok to be %v, got %v", tt.expectedOK, ok)
+			}
+			if ok && stage != tt.expectedStage {
+				t.Errorf("Expected stage %d, got %d", tt.expectedStage, stage)
+			}
+		})
+	}
+}
+
+func TestPipelineChainNewStageJobs(t *testing.T) {
+	chain := newTestChain(time.Now())
+	transform := &Transform{
+		ObjectMeta: metav1.ObjectMeta{Name: "thumbnail", Namespace: "default"},
+		Spec: TransformSpec{
+			Sink: &pipelinesmeta.SourceSinkConfig{
+				MinIO: &pipelinesmeta.MinIOConfig{Bucket: "thumbnails", Prefix: "thumbs/{{ .SrcName }}.png"},
+			},
+		},
+	}
+	parent := &Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "transcode-abc", Namespace: "default"},
+		Spec: JobSpec{
+			Sinks: []*pipelinesmeta.Object{
+				{Name: "out/video-720.mp4", Config: &pipelinesmeta.SourceSinkConfig{MinIO: &pipelinesmeta.MinIOConfig{Bucket: "transcoded"}}},
+				{Name: "out/video-1080.mp4", Config: &pipelinesmeta.SourceSinkConfig{MinIO: &pipelinesmeta.MinIOConfig{Bucket: "transcoded"}}},
+			},
+		},
+	}
+
+	jobs := chain.NewStageJobs(1, parent, transform)
+	if len(jobs) != len(parent.Spec.Sinks) {
+		t.Fatalf("Expected %d jobs, got %d", len(parent.Spec.Sinks), len(jobs))
+	}
+
+	tests := []struct {
+		srcName  string
+		sinkName string
+	}{
+		{"out/video-720.mp4", "thumbs/video-720.png"},
+		{"out/video-1080.mp4", "thumbs/video-1080.png"},
+	}
+	for idx, tt := range tests {
+		job := jobs[idx]
+		if job.Spec.Source.Name != tt.srcName {
+			t.Errorf("Expected source %q, got %q", tt.srcName, job.Spec.Source.Name)
+		}
+		if len(job.Spec.Sinks) != 1 || job.Spec.Sinks[0].Name != tt.sinkName {
+			t.Errorf("Expected a single sink %q, got %+v", tt.sinkName, job.Spec.Sinks)
+		}
+		if job.Spec.PipelineReference.Name != "thumbnail" || job.Spec.PipelineReference.Kind != PipelineTransform {
+			t.Errorf("Expected a reference to the thumbnail Transform, got %+v", job.Spec.PipelineReference)
+		}
+		labels := job.GetLabels()
+		if labels[pipelinesmeta.JobChainLabel] != "chain" || labels[pipelinesmeta.JobChainStageLabel] != "1" {
+			t.Errorf("Expected chain labels for stage 1, got %v", labels)
+		}
+		if labels[pipelinesmeta.JobBucketLabel] != "transcoded" {
+			t.Errorf("Expected the bucket label of the parent output, got %q", labels[pipelinesmeta.JobBucketLabel])
+		}
+		if stage, ok := chain.StageForJob(job); !ok || stage != 1 {
+			t.Errorf("Expected the job to belong to stage 1 of the chain, got %d, %v", stage, ok)
+		}
+	}
+
+	if jobs[0].GetName() == jobs[1].GetName() {
+		t.Errorf("Expected unique job names, got %q twice", jobs[0].GetName())
+	}
+	again := chain.NewStageJobs(1, parent, transform)
+	for idx := range jobs {
+		if again[idx].GetName() != jobs[idx].GetName() {
+			t.Errorf("Expected job names to be stable, got %q and %q", jobs[idx].GetName(), again[idx].GetName())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PipelineTemplateSpec defines the desired state of PipelineTemplate
+type PipelineTemplateSpec struct {
+	// The parameters that can be given to the template by pipelines referencing it.
+	Parameters []*pipelinesmeta.TemplateParameter `json:"parameters,omitempty"`
+	// The element configurations of the template. Parameters can be referenced in element names
+	// and property values, e.g. `bitrate: "{{ .bitrate }}"`.
+	Elements []*pipelinesmeta.ElementConfig `json:"elements"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Parameters",type="string",JSONPath=`.spec.parameters[*].name`
+
+// PipelineTemplate is the Schema for the pipelinetemplates API
+type PipelineTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PipelineTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PipelineTemplateList contains a list of PipelineTemplate
+type PipelineTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PipelineTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PipelineTemplate{}, &PipelineTemplateList{})
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// Render returns a copy of the given pipeline configuration with its elements rendered from
+// this template. The parameters are taken from the template reference on the configuration.
+func (t *PipelineTemplate) Render(cfg *pipelinesmeta.PipelineConfig) (*pipelinesmeta.PipelineConfig, error) {
+	var given map[string]string
+	if cfg.Template != nil {
+		given = cfg.Template.Parameters
+	}
+
+	values := make(map[string]string)
+	for _, param := range t.Spec.Parameters {
+		if value, ok := given[param.Name]; ok {
+			values[param.Name] = value
+			continue
+		}
+		if param.Default == nil {
+			return nil, fmt.Errorf("No value given for required parameter %q of template %q", param.Name, t.GetName())
+		}
+		values[param.Name] = *param.Default
+	}
+	for name := range given {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("Parameter %q is not declared by template %q", name, t.GetName())
+		}
+	}
+
+	rendered := cfg.DeepCopy()
+	rendered.Elements = make([]*pipelinesmeta.ElementConfig, len(t.Spec.Elements))
+	for idx, element := range t.Spec.Elements {
+		out := element.DeepCopy()
+		var err error
+		if out.Name, err = renderTemplateValue(out.Name, values); err != nil {
+			return nil, fmt.Errorf("Failed to render name of element %d in template %q: %s", idx, t.GetName(), err.Error())
+		}
+		for key, value := range out.Properties {
+			// Only string values can hold parameters
+			str, ok := value.GetString()
+			if !ok {
+				continue
+			}
+			if str, err = renderTemplateValue(str, values); err != nil {
+				return nil, fmt.Errorf("Failed to render property %q of element %q in template %q: %s", key, out.Name, t.GetName(), err.Error())
+			}
+			out.Properties[key] = pipelinesmeta.NewStringPropertyValue(str)
+		}
+		rendered.Elements[idx] = out
+	}
+	return rendered, nil
+}
+
+func renderTemplateValue(value string, params map[string]string) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	t, err := template.New("").Option("missingkey=error").Funcs(sprig.TxtFuncMap()).Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+func TestPipelineTemplateRender(t *testing.T) {
+	defaultBitrate := "2048"
+	template := &PipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "h264"},
+		Spec: PipelineTemplateSpec{
+			Parameters: []*pipelinesmeta.TemplateParameter{
+				{Name: "encoder"},
+				{Name: "bitrate", Default: &defaultBitrate},
+			},
+			Elements: []*pipelinesmeta.ElementConfig{
+				{Name: "decodebin"},
+				{
+					Name: "{{ .encoder }}",
+					Properties: map[string]pipelinesmeta.PropertyValue{
+						"bitrate":  pipelinesmeta.NewStringPropertyValue("{{ .bitrate }}"),
+						"tune":     pipelinesmeta.NewStringPropertyValue("zerolatency"),
+						"key-int":  {Raw: []byte(`60`)},
+						"speed":    pipelinesmeta.NewStringPropertyValue("{{ .bitrate | int | mul 2 }}"),
+						"disabled": {Raw: []byte(`false`)},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		elements         []*pipelinesmeta.ElementConfig
+		params           map[string]string
+		expectedEncoder  string
+		expectedBitrate  string
+		expectedSpeed    string
+		expectErr        bool
+		expectedErrorMsg string
+	}{
+		{
+			name:            "defaults",
+			params:          map[string]string{"encoder": "x264enc"},
+			expectedEncoder: "x264enc",
+			expectedBitrate: "2048",
+			expectedSpeed:   "4096",
+		},
+		{
+			name:            "given values",
+			params:          map[string]string{"encoder": "nvh264enc", "bitrate": "4000"},
+			expectedEncoder: "nvh264enc",
+			expectedBitrate: "4000",
+			expectedSpeed:   "8000",
+		},
+		{
+			name:             "missing required parameter",
+			params:           map[string]string{"bitrate": "4000"},
+			expectErr:        true,
+			expectedErrorMsg: `No value given for required parameter "encoder" of template "h264"`,
+		},
+		{
+			name:             "undeclared parameter",
+			params:           map[string]string{"encoder": "x264enc", "preset": "fast"},
+			expectErr:        true,
+			expectedErrorMsg: `Parameter "preset" is not declared by template "h264"`,
+		},
+		{
+			name: "reference to an undeclared parameter",
+			elements: []*pipelinesmeta.ElementConfig{
+				{Name: "{{ .encoder }}"},
+				{Name: "{{ .muxer }}"},
+			},
+			params:    map[string]string{"encoder": "x264enc"},
+			expectErr: true,
+		},
+		{
+			name: "invalid template",
+			elements: []*pipelinesmeta.ElementConfig{
+				{Name: "{{ .encoder"},
+			},
+			params:    map[string]string{"encoder": "x264enc"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.DeepCopy()
+			if tt.elements != nil {
+				tmpl.Spec.Elements = tt.elements
+			}
+			cfg := &pipelinesmeta.PipelineConfig{
+				Template: &pipelinesmeta.TemplateReference{Name: "h264", Parameters: tt.params},
+			}
+
+			rendered, err := tmpl.Render(cfg)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %+v", rendered)
+				}
+				if tt.expectedErrorMsg != "" && err.Error() != tt.expectedErrorMsg {
+					t.Errorf("Expected error %q, got %q", tt.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rendered.Elements) != 2 || rendered.Elements[0].Name != "decodebin" {
+				t.Fatalf("Expected the elements of the template, got %+v", rendered.Elements)
+			}
+			encoder := rendered.Elements[1]
+			if encoder.Name != tt.expectedEncoder {
+				t.Errorf("Expected encoder %q, got %q", tt.expectedEncoder, encoder.Name)
+			}
+			for prop, expected := range map[string]string{
+				"bitrate": tt.expectedBitrate,
+				"speed":   tt.expectedSpeed,
+				"tune":    "zerolatency",
+			} {
+				if value, ok := encoder.Properties[prop].GetString(); !ok || value != expected {
+					t.Errorf("Expected property %s to be %q, got %q", prop, expected, value)
+				}
+			}
+			for prop, expected := range map[string]string{"key-int": "60", "disabled": "false"} {
+				if raw := string(encoder.Properties[prop].Raw); raw != expected {
+					t.Errorf("Expected non-string property %s to be kept as %s, got %s", prop, expected, raw)
+				}
+			}
+			if rendered.Template == nil || rendered.Template.Name != "h264" {
+				t.Errorf("Expected the template reference to be kept, got %+v", rendered.Template)
+			}
+
+			// The template itself is left untouched
+			if name := tmpl.Spec.Elements[1].Name; name != "{{ .encoder }}" {
+				t.Errorf("Expected the template to be unchanged, got element name %q", name)
+			}
+		})
+	}
+}
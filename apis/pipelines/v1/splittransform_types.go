@@ -48,6 +48,8 @@ type SplitTransformSpec struct {
 type SplitTransformStatus struct {
 	// Conditions represent the latest available observations of a splittransform's state
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// The effective pipeline configuration rendered from the template referenced by the pipeline.
+	Pipeline *pipelinesmeta.PipelineConfig `json:"pipeline,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -30,8 +30,14 @@ func (t *SplitTransform) GetPipelineKind() pipelinesmeta.PipelineKind {
 	return PipelineSplitTransform
 }
 
-// GetPipelineConfig returns the PipelineConfig.
-func (t *SplitTransform) GetPipelineConfig() *pipelinesmeta.PipelineConfig { return t.Spec.Pipeline }
+// GetPipelineConfig returns the PipelineConfig. When the pipeline references a template, this is
+// the configuration rendered by the controller.
+func (t *SplitTransform) GetPipelineConfig() *pipelinesmeta.PipelineConfig {
+	if t.Spec.Pipeline != nil && t.Spec.Pipeline.Template != nil {
+		return t.Status.Pipeline
+	}
+	return t.Spec.Pipeline
+}
 
 // GetSrcConfig will return the src config for this pipeline merged with the globals.
 func (t *SplitTransform) GetSrcConfig() *pipelinesmeta.SourceSinkConfig {
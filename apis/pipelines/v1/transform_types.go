@@ -30,18 +30,28 @@ const (
 type TransformSpec struct {
 	// Global configurations to apply when omitted from the src or sink configurations.
 	Globals *pipelinesmeta.SourceSinkConfig `json:"globals,omitempty"`
-	// Configurations for src object to the pipeline.
-	Src *pipelinesmeta.SourceSinkConfig `json:"src"`
+	// Configurations for src object to the pipeline. This may be omitted when the Transform is only
+	// used as a later stage of a PipelineChain, in which case no objects are watched for.
+	Src *pipelinesmeta.SourceSinkConfig `json:"src,omitempty"`
 	// Configurations for sink objects from the pipeline.
 	Sink *pipelinesmeta.SourceSinkConfig `json:"sink"`
 	// The configuration for the processing pipeline
 	Pipeline *pipelinesmeta.PipelineConfig `json:"pipeline"`
+	// When set, objects in the src are processed on this schedule instead of as they are created.
+	Schedule *pipelinesmeta.ScheduleConfig `json:"schedule,omitempty"`
+	// Validate the pipeline with a one-off job whenever the Transform changes. The result is
+	// reported in a Valid condition.
+	Validation *pipelinesmeta.ValidationConfig `json:"validation,omitempty"`
 }
 
 // TransformStatus defines the observed state of Transform
 type TransformStatus struct {
 	// Conditions represent the latest available observations of a transform's state
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// The effective pipeline configuration rendered from the template referenced by the pipeline.
+	Pipeline *pipelinesmeta.PipelineConfig `json:"pipeline,omitempty"`
+	// The results of scheduled processing when a schedule is configured.
+	Schedule *pipelinesmeta.ScheduleStatus `json:"schedule,omitempty"`
 }
 
 // +kubebuilder:object:root=true
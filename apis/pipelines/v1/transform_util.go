@@ -30,8 +30,14 @@ func (t *Transform) GetPipelineKind() pipelinesmeta.PipelineKind {
 	return PipelineTransform
 }
 
-// GetPipelineConfig returns the PipelineConfig.
-func (t *Transform) GetPipelineConfig() *pipelinesmeta.PipelineConfig { return t.Spec.Pipeline }
+// GetPipelineConfig returns the PipelineConfig. When the pipeline references a template, this is
+// the configuration rendered by the controller.
+func (t *Transform) GetPipelineConfig() *pipelinesmeta.PipelineConfig {
+	if t.Spec.Pipeline != nil && t.Spec.Pipeline.Template != nil {
+		return t.Status.Pipeline
+	}
+	return t.Spec.Pipeline
+}
 
 // GetSrcConfig will return the src config for this pipeline merged with the globals.
 func (t *Transform) GetSrcConfig() *pipelinesmeta.SourceSinkConfig {
@@ -53,3 +59,15 @@ func (t *Transform) GetSinkObjects(srcKey string) []*pipelinesmeta.Object {
 		},
 	}
 }
+
+// GetSchedule returns the schedule for processing the src, or nil if objects are processed
+// as they are created.
+func (t *Transform) GetSchedule() *pipelinesmeta.ScheduleConfig { return t.Spec.Schedule }
+
+// GetScheduleStatus returns the results of scheduled processing.
+func (t *Transform) GetScheduleStatus() *pipelinesmeta.ScheduleStatus { return t.Status.Schedule }
+
+// SetScheduleStatus sets the results of scheduled processing.
+func (t *Transform) SetScheduleStatus(status *pipelinesmeta.ScheduleStatus) {
+	t.Status.Schedule = status
+}
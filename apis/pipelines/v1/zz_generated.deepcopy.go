@@ -26,6 +26,152 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChainStage) DeepCopyInto(out *ChainStage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChainStage.
+func (in *ChainStage) DeepCopy() *ChainStage {
+	if in == nil {
+		return nil
+	}
+	out := new(ChainStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChainStageStatus) DeepCopyInto(out *ChainStageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChainStageStatus.
+func (in *ChainStageStatus) DeepCopy() *ChainStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChainStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Concat) DeepCopyInto(out *Concat) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Concat.
+func (in *Concat) DeepCopy() *Concat {
+	if in == nil {
+		return nil
+	}
+	out := new(Concat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Concat) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcatList) DeepCopyInto(out *ConcatList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Concat, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcatList.
+func (in *ConcatList) DeepCopy() *ConcatList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcatList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConcatList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcatSpec) DeepCopyInto(out *ConcatSpec) {
+	*out = *in
+	if in.Globals != nil {
+		in, out := &in.Globals, &out.Globals
+		*out = new(metav1.SourceSinkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Src != nil {
+		in, out := &in.Src, &out.Src
+		*out = new(metav1.SourceSinkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(metav1.SourceSinkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pipeline != nil {
+		in, out := &in.Pipeline, &out.Pipeline
+		*out = new(metav1.PipelineConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcatSpec.
+func (in *ConcatSpec) DeepCopy() *ConcatSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcatSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcatStatus) DeepCopyInto(out *ConcatStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]apismetav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcatStatus.
+func (in *ConcatStatus) DeepCopy() *ConcatStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcatStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Job) DeepCopyInto(out *Job) {
 	*out = *in
@@ -105,6 +251,17 @@ func (in *JobSpec) DeepCopyInto(out *JobSpec) {
 			}
 		}
 	}
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make([]*metav1.Object, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(metav1.Object)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSpec.
@@ -127,6 +284,11 @@ func (in *JobStatus) DeepCopyInto(out *JobStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Result != nil {
+		in, out := &in.Result, &out.Result
+		*out = new(metav1.JobResult)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobStatus.
@@ -139,6 +301,329 @@ func (in *JobStatus) DeepCopy() *JobStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LiveIngest) DeepCopyInto(out *LiveIngest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiveIngest.
+func (in *LiveIngest) DeepCopy() *LiveIngest {
+	if in == nil {
+		return nil
+	}
+	out := new(LiveIngest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LiveIngest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LiveIngestList) DeepCopyInto(out *LiveIngestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LiveIngest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiveIngestList.
+func (in *LiveIngestList) DeepCopy() *LiveIngestList {
+	if in == nil {
+		return nil
+	}
+	out := new(LiveIngestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LiveIngestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LiveIngestSpec) DeepCopyInto(out *LiveIngestSpec) {
+	*out = *in
+	if in.Src != nil {
+		in, out := &in.Src, &out.Src
+		*out = new(metav1.LiveSourceConfig)
+		**out = **in
+	}
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(metav1.SourceSinkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Segments != nil {
+		in, out := &in.Segments, &out.Segments
+		*out = new(metav1.SegmentConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pipeline != nil {
+		in, out := &in.Pipeline, &out.Pipeline
+		*out = new(metav1.PipelineConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiveIngestSpec.
+func (in *LiveIngestSpec) DeepCopy() *LiveIngestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LiveIngestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LiveIngestStatus) DeepCopyInto(out *LiveIngestStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]apismetav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiveIngestStatus.
+func (in *LiveIngestStatus) DeepCopy() *LiveIngestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LiveIngestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineChain) DeepCopyInto(out *PipelineChain) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineChain.
+func (in *PipelineChain) DeepCopy() *PipelineChain {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineChain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineChain) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineChainList) DeepCopyInto(out *PipelineChainList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PipelineChain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineChainList.
+func (in *PipelineChainList) DeepCopy() *PipelineChainList {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineChainList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineChainList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineChainSpec) DeepCopyInto(out *PipelineChainSpec) {
+	*out = *in
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]*ChainStage, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChainStage)
+				**out = **in
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineChainSpec.
+func (in *PipelineChainSpec) DeepCopy() *PipelineChainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineChainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineChainStatus) DeepCopyInto(out *PipelineChainStatus) {
+	*out = *in
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]ChainStageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]apismetav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineChainStatus.
+func (in *PipelineChainStatus) DeepCopy() *PipelineChainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineChainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTemplate) DeepCopyInto(out *PipelineTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplate.
+func (in *PipelineTemplate) DeepCopy() *PipelineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTemplateList) DeepCopyInto(out *PipelineTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PipelineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplateList.
+func (in *PipelineTemplateList) DeepCopy() *PipelineTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTemplateSpec) DeepCopyInto(out *PipelineTemplateSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]*metav1.TemplateParameter, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(metav1.TemplateParameter)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Elements != nil {
+		in, out := &in.Elements, &out.Elements
+		*out = make([]*metav1.ElementConfig, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(metav1.ElementConfig)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplateSpec.
+func (in *PipelineTemplateSpec) DeepCopy() *PipelineTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SplitTransform) DeepCopyInto(out *SplitTransform) {
 	*out = *in
@@ -248,6 +733,11 @@ func (in *SplitTransformStatus) DeepCopyInto(out *SplitTransformStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Pipeline != nil {
+		in, out := &in.Pipeline, &out.Pipeline
+		*out = new(metav1.PipelineConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplitTransformStatus.
@@ -342,6 +832,16 @@ func (in *TransformSpec) DeepCopyInto(out *TransformSpec) {
 		*out = new(metav1.PipelineConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(metav1.ScheduleConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(metav1.ValidationConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformSpec.
@@ -364,6 +864,16 @@ func (in *TransformStatus) DeepCopyInto(out *TransformStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Pipeline != nil {
+		in, out := &in.Pipeline, &out.Pipeline
+		*out = new(metav1.PipelineConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(metav1.ScheduleStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformStatus.
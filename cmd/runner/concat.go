@@ -0,0 +1,168 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	"github.com/tinyzimmer/gst-pipeline-operator/pkg/util"
+)
+
+// concatStreams maps the reserved concat aliases to the media type prefix of the
+// decoded streams they receive.
+var concatStreams = map[string]string{
+	pipelinesmeta.GoToConcatVideo: "video/",
+	pipelinesmeta.GoToConcatAudio: "audio/",
+}
+
+// verifyInputObjects makes sure every object referenced by a manifest exists before the
+// pipeline is built, so that a missing object fails the job with a clear error.
+func verifyInputObjects(inputs []*pipelinesmeta.Object) error {
+	missing := make([]string, 0)
+	for _, input := range inputs {
+		mc, err := util.GetMinIOClient(input.Config.MinIO, util.MinIOSrcCredentialsFromEnv())
+		if err != nil {
+			return err
+		}
+		sse, err := util.GetServerSideEncryption(input.Config.MinIO, pipelinesmeta.MinIOSrcCustomerKeyEnvVar)
+		if err != nil {
+			return err
+		}
+		if _, err := mc.StatObject(context.Background(), input.Config.MinIO.GetBucket(), input.Name, minio.StatObjectOptions{
+			ServerSideEncryption: util.ReadEncryption(sse),
+		}); err != nil {
+			if resErr := minio.ToErrorResponse(err); resErr.Code == "NoSuchKey" {
+				missing = append(missing, input.Name)
+				continue
+			}
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("The following objects referenced by the manifest do not exist: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// addConcatSources adds a source and decoder for each input object to the pipeline, and a concat
+// element for each stream type referenced in the pipeline configuration. The returned configuration
+// includes the concat elements so they can be referenced by their aliases.
+func addConcatSources(pipeline *gst.Pipeline, pipelineCfg pipelinesmeta.GstLaunchConfig, inputs []*pipelinesmeta.Object) (pipelinesmeta.GstLaunchConfig, error) {
+	concats := make(map[string]*gst.Element)
+	for alias := range concatStreams {
+		if !pipelineCfg.HasGoTo(alias) {
+			continue
+		}
+		cfg := &pipelinesmeta.GstElementConfig{ElementConfig: &pipelinesmeta.ElementConfig{Name: "concat", Alias: alias}}
+		elem, err := elementForPipeline(pipeline, cfg)
+		if err != nil {
+			return nil, err
+		}
+		concats[alias] = elem
+		pipelineCfg = append(pipelineCfg, cfg)
+	}
+	if len(concats) == 0 {
+		return nil, fmt.Errorf("Concat pipelines must contain a goto to %s or %s", pipelinesmeta.GoToConcatVideo, pipelinesmeta.GoToConcatAudio)
+	}
+
+	for _, input := range inputs {
+		src, err := makeSrcElement(input)
+		if err != nil {
+			return nil, err
+		}
+		decodebin, err := gst.NewElement("decodebin")
+		if err != nil {
+			return nil, err
+		}
+		pipeline.AddMany(src, decodebin)
+		if err := src.Link(decodebin); err != nil {
+			return nil, err
+		}
+
+		// Request the concat pads up front. The concat element plays its sink pads in the order
+		// they were requested, so this keeps the order of the manifest regardless of when each
+		// decoder exposes its streams.
+		sinkpads := make(map[string]*gst.Pad)
+		for alias, concat := range concats {
+			pad := requestPad(concat, "sink_%u")
+			if pad == nil {
+				return nil, errors.New("Could not request a sink pad from concat")
+			}
+			sinkpads[alias] = pad
+		}
+
+		key := input.Name
+		decodebin.Connect("pad-added", func(self *gst.Element, srcpad *gst.Pad) {
+			caps := srcpad.CurrentCaps()
+			if caps == nil {
+				caps = srcpad.QueryCaps(nil)
+			}
+			media := caps.GetStructureAt(0).Name()
+			for alias, prefix := range concatStreams {
+				sinkpad, ok := sinkpads[alias]
+				if !ok || sinkpad.IsLinked() || !strings.HasPrefix(media, prefix) {
+					continue
+				}
+				if ret := srcpad.Link(sinkpad); ret != gst.PadLinkOK {
+					self.ErrorMessage(gst.DomainCore, gst.CoreErrorNegotiation,
+						fmt.Sprintf("Failed to link %s stream from %s to %s: %s", media, key, alias, ret.String()), "")
+				}
+				return
+			}
+			// Streams that are not used still need to be consumed
+			log.Info("Discarding unused stream from input", "Key", key, "Media", media)
+			if err := discardPad(pipeline, srcpad); err != nil {
+				self.ErrorMessage(gst.DomainCore, gst.CoreErrorFailed, err.Error(), "")
+			}
+		})
+
+		decodebin.Connect("no-more-pads", func(self *gst.Element) {
+			// Release any pads for streams this input does not have, otherwise concat will wait
+			// on them forever.
+			for alias, sinkpad := range sinkpads {
+				if !sinkpad.IsLinked() {
+					log.Info("Input has no stream for concat", "Key", key, "Alias", alias)
+					releaseRequestPad(concats[alias], sinkpad)
+				}
+			}
+		})
+	}
+
+	return pipelineCfg, nil
+}
+
+// discardPad links the given pad to a fakesink.
+func discardPad(pipeline *gst.Pipeline, srcpad *gst.Pad) error {
+	fakesink, err := gst.NewElement("fakesink")
+	if err != nil {
+		return err
+	}
+	fakesink.SetProperty("async", false)
+	pipeline.Add(fakesink)
+	fakesink.SyncStateWithParent()
+	if ret := srcpad.Link(fakesink.GetStaticPad("sink")); ret != gst.PadLinkOK {
+		return fmt.Errorf("Failed to link pad %s to fakesink: %s", srcpad.GetName(), ret.String())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/tinyzimmer/go-glib/glib"
+	"github.com/tinyzimmer/go-gst/gst"
+	"github.com/tinyzimmer/go-gst/gst/pbutils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	"github.com/tinyzimmer/gst-pipeline-operator/pkg/util"
+)
+
+// discoverSource probes the source object of the pipeline and returns what it contains.
+func discoverSource(spec *pipelineSpec) (*pipelinesmeta.MediaInfo, error) {
+	log.Info("Discovering the contents of the source object", "Key", spec.src.Name)
+	media, err := discoverObject(spec.src, spec.cfg.Discover.GetTimeout())
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Discovered source object", "Container", media.Container, "Duration", media.Duration, "Streams", len(media.Streams))
+	return media, nil
+}
+
+// discoverObject runs a discoverer against a presigned URL for the given object. The discoverer reads
+// the URL with an HTTP source set up with the same TLS configuration as miniosrc. Local files are
+// read directly.
+func discoverObject(obj *pipelinesmeta.Object, timeout time.Duration) (*pipelinesmeta.MediaInfo, error) {
+	if isLocalObject(obj) {
+		path, err := filepath.Abs(obj.Name)
+		if err != nil {
+			return nil, err
+		}
+		discoverer, err := pbutils.NewDiscoverer(timeout)
+		if err != nil {
+			return nil, err
+		}
+		return runDiscoverer(discoverer, obj, (&url.URL{Scheme: "file", Path: path}).String(), timeout)
+	}
+
+	cfg := obj.Config.MinIO
+	if cfg.Encryption.IsCustomerKey() {
+		// The customer key has to be sent in headers, which a presigned URL cannot carry
+		return nil, errors.New("Objects encrypted with SSE-C cannot be discovered")
+	}
+	mc, err := util.GetMinIOClient(cfg, util.MinIOSrcCredentialsFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	// The URL must stay valid for any range requests made while probing
+	var params url.Values
+	if obj.VersionID != "" {
+		params = url.Values{"versionId": []string{obj.VersionID}}
+	}
+	uri, err := mc.PresignedGetObject(context.Background(), cfg.GetBucket(), obj.Name, timeout+time.Minute, params)
+	if err != nil {
+		return nil, err
+	}
+	caFile, err := writeRootCA(cfg, "/tmp/ca-src.crt")
+	if err != nil {
+		return nil, err
+	}
+
+	discoverer, err := pbutils.NewDiscoverer(timeout)
+	if err != nil {
+		return nil, err
+	}
+	discoverer.Connect("source-setup", func(self *glib.Object, source *gst.Element) {
+		if _, err := source.GetPropertyType("ssl-ca-file"); err != nil {
+			return
+		}
+		if caFile != "" {
+			source.SetProperty("ssl-ca-file", caFile)
+		}
+		if cfg.InsecureSkipVerify {
+			source.SetProperty("ssl-strict", false)
+		}
+	})
+
+	return runDiscoverer(discoverer, obj, uri.String(), timeout)
+}
+
+// runDiscoverer discovers the contents of the given URI for obj.
+func runDiscoverer(discoverer *pbutils.Discoverer, obj *pipelinesmeta.Object, uri string, timeout time.Duration) (*pipelinesmeta.MediaInfo, error) {
+	info, err := discoverer.DiscoverURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch info.GetResult() {
+	case pbutils.DiscovererResultOK:
+	case pbutils.DiscovererResultMissingPlugins:
+		log.Info("Some plugins are missing to fully discover the object", "Key", obj.Name)
+	case pbutils.DiscovererResultTimeout:
+		return nil, fmt.Errorf("Timed out after %s discovering %s", timeout, obj.Name)
+	default:
+		return nil, fmt.Errorf("Could not discover the contents of %s", obj.Name)
+	}
+	return mediaInfoFromDiscoverer(info), nil
+}
+
+func mediaInfoFromDiscoverer(info *pbutils.DiscovererInfo) *pipelinesmeta.MediaInfo {
+	media := &pipelinesmeta.MediaInfo{Seekable: info.GetSeekable()}
+	if duration := info.GetDuration(); duration > 0 {
+		media.Duration = &metav1.Duration{Duration: duration}
+	}
+	if top := info.GetStreamInfo(); top != nil && top.GetStreamTypeNick() == "container" {
+		media.Container = streamCodec(top)
+	}
+
+	for _, video := range info.GetVideoStreams() {
+		stream := &pipelinesmeta.MediaStream{
+			Type:    "video",
+			Codec:   streamCodec(video.DiscovererStreamInfo),
+			Bitrate: video.GetBitrate(),
+			Width:   video.GetWidth(),
+			Height:  video.GetHeight(),
+		}
+		if video.IsImage() {
+			stream.Type = "image"
+		} else if num := video.GetFramerateNum(); num > 0 {
+			stream.Framerate = fmt.Sprintf("%d/%d", num, video.GetFramerateDenom())
+		}
+		media.Streams = append(media.Streams, stream)
+	}
+	for _, audio := range info.GetAudioStreams() {
+		media.Streams = append(media.Streams, &pipelinesmeta.MediaStream{
+			Type:       "audio",
+			Codec:      streamCodec(audio.DiscovererStreamInfo),
+			Bitrate:    audio.GetBitate(),
+			SampleRate: audio.GetSampleRate(),
+			Channels:   audio.GetChannels(),
+			Language:   audio.GetLanguage(),
+		})
+	}
+	for _, subtitle := range info.GetSubtitleStreams() {
+		media.Streams = append(media.Streams, &pipelinesmeta.MediaStream{
+			Type:     "subtitle",
+			Codec:    streamCodec(subtitle.DiscovererStreamInfo),
+			Language: subtitle.GetLanguage(),
+		})
+	}
+	return media
+}
+
+// streamCodec returns the media type of the caps of the given stream.
+func streamCodec(stream *pbutils.DiscovererStreamInfo) string {
+	caps := stream.GetCaps()
+	if caps == nil || caps.GetSize() == 0 {
+		return ""
+	}
+	return caps.GetStructureAt(0).Name()
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-graphviz"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	"github.com/tinyzimmer/gst-pipeline-operator/pkg/util"
+)
+
+// Reasons for capturing a dot graph of the pipeline, used in the names of the files.
+const (
+	dotReasonInterval = "interval"
+	dotReasonError    = "error"
+	dotReasonEOS      = "eos"
+	dotReasonStall    = "stall"
+)
+
+// dotQueueSize is the number of captures that can wait for upload. Captures taken on an interval or
+// a state change are dropped while the queue is full.
+const dotQueueSize = 8
+
+// dotDumper captures dot graphs of a pipeline and uploads them, along with rendered images when
+// configured, to the debug destination or next to the source object. Graphs are snapshotted where
+// they are captured, but rendered and uploaded in the background, so the bus watch on the main loop
+// is never held up by the network.
+type dotDumper struct {
+	pipeline *gst.Pipeline
+	cfg      *pipelinesmeta.PipelineConfig
+
+	mc        *minio.Client
+	sse       encrypt.ServerSide
+	outbucket string
+	outpath   string
+
+	// only used by the upload goroutine, the graphviz context is not safe for concurrent use
+	g *graphviz.Graphviz
+
+	// guards the queue, which is closed once it is drained
+	mu       sync.Mutex
+	queue    chan *dotCapture
+	closed   bool
+	uploaded chan struct{}
+}
+
+// dotCapture is a snapshot of the pipeline graph waiting for upload.
+type dotCapture struct {
+	name    string
+	dotdata []byte
+}
+
+// newDotDumper returns a dotDumper for the pipeline, or nil if dot graphs are not enabled or there is
+// nowhere to write them.
+func newDotDumper(pipeline *gst.Pipeline, cfg *pipelinesmeta.PipelineConfig, srcobject *pipelinesmeta.Object) *dotDumper {
+	if !cfg.DoDotDump() {
+		return nil
+	}
+
+	var minioCfg *pipelinesmeta.MinIOConfig
+	var creds util.MinIOCredentialsGetter
+	var customerKeyEnvVar, srcKey string
+	if dest := cfg.GetDebugDestination(); dest != nil {
+		minioCfg, creds = dest.MinIO, util.MinIODebugCredentialsFromEnv()
+		customerKeyEnvVar = pipelinesmeta.MinIODebugCustomerKeyEnvVar
+		switch {
+		case srcobject != nil && isLocalObject(srcobject):
+			srcKey = filepath.Base(srcobject.Name)
+		case srcobject != nil:
+			srcKey = srcobject.Name
+		default:
+			// Graphs of live pipelines are kept apart by the pod recording the stream
+			srcKey, _ = os.Hostname()
+		}
+	} else {
+		// Live pipelines and local files have no src object to write dot graphs next to
+		if srcobject == nil || isLocalObject(srcobject) {
+			return nil
+		}
+		minioCfg, creds, srcKey = srcobject.Config.MinIO, util.MinIOSrcCredentialsFromEnv(), srcobject.Name
+		customerKeyEnvVar = pipelinesmeta.MinIOSrcCustomerKeyEnvVar
+	}
+
+	sse, err := util.GetServerSideEncryption(minioCfg, customerKeyEnvVar)
+	if err != nil {
+		log.Error(err, "Could not read the encryption for dot graph debugging")
+		return nil
+	}
+
+	mc, err := util.GetMinIOClient(minioCfg, creds)
+	if err != nil {
+		log.Error(err, "Could not create minio client for dot graph debugging")
+		return nil
+	}
+	d := &dotDumper{
+		pipeline:  pipeline,
+		cfg:       cfg,
+		mc:        mc,
+		sse:       sse,
+		outbucket: minioCfg.GetBucket(),
+		outpath:   cfg.GetDotPath(srcKey),
+		queue:     make(chan *dotCapture, dotQueueSize),
+		uploaded:  make(chan struct{}),
+	}
+	go d.uploadQueued()
+	return d
+}
+
+// stateChangeReason returns the reason for a capture triggered by the given state change.
+func stateChangeReason(oldState, newState gst.State) string {
+	return strings.ToLower(fmt.Sprintf("%s-to-%s", oldState.String(), newState.String()))
+}
+
+// capture snapshots the pipeline and queues the graph for upload, tagged with the reason for the
+// capture unless it was taken on the regular interval. Captures for the end of the pipeline wait for
+// room in the queue, the others are dropped when it is full. Errors are logged, since debug output
+// should never interrupt the pipeline.
+func (d *dotDumper) capture(reason string) {
+	name := "pipeline"
+	if d.cfg.TimestampDotGraphs() {
+		name = fmt.Sprintf("%s_%s", name, time.Now().UTC().Format(time.RFC3339))
+	}
+	if reason != dotReasonInterval {
+		name = fmt.Sprintf("%s_%s", name, reason)
+	}
+	c := &dotCapture{
+		name:    name,
+		dotdata: []byte(d.pipeline.DebugBinToDotData(gst.DebugGraphShowAll)),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	switch reason {
+	case dotReasonEOS, dotReasonError, dotReasonStall:
+		d.queue <- c
+	default:
+		select {
+		case d.queue <- c:
+		default:
+			log.Info("Dropping dot graph capture, uploads are falling behind", "Reason", reason)
+		}
+	}
+}
+
+// drain stops accepting captures and waits up to the given timeout for the queued ones to be
+// uploaded.
+func (d *dotDumper) drain(timeout time.Duration) {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.uploaded:
+	case <-time.After(timeout):
+		log.Info("Timed out uploading dot graphs", "Timeout", timeout)
+	}
+}
+
+// uploadQueued uploads captures until the queue is drained.
+func (d *dotDumper) uploadQueued() {
+	defer close(d.uploaded)
+	for c := range d.queue {
+		d.upload(c)
+	}
+}
+
+// upload renders the captured graph when configured and uploads it.
+func (d *dotDumper) upload(c *dotCapture) {
+	dotname := path.Join(d.outpath, fmt.Sprintf("%s.dot", c.name))
+
+	var imgname string
+	var imgdata []byte
+	if render := strings.ToLower(d.cfg.GetDotRenderFormat()); render != "" {
+		var format graphviz.Format
+		switch render {
+		case "png":
+			format = graphviz.PNG
+		case "svg":
+			format = graphviz.SVG
+		case "jpg":
+			format = graphviz.JPG
+		}
+		if format != "" {
+			if d.g == nil {
+				d.g = graphviz.New()
+			}
+			graph, err := graphviz.ParseBytes(c.dotdata)
+			if err != nil {
+				log.Error(err, "Failed to parse pipeline dot data")
+				return
+			}
+			var buf bytes.Buffer
+			if err := d.g.Render(graph, format, &buf); err != nil {
+				log.Error(err, fmt.Sprintf("Failed to convert dotdata to %s", strings.ToUpper(render)))
+				return
+			}
+			imgname = path.Join(d.outpath, fmt.Sprintf("%s.%s", c.name, render))
+			imgdata = buf.Bytes()
+		}
+	}
+
+	_, err := d.mc.PutObject(context.Background(), d.outbucket, dotname, bytes.NewBuffer(c.dotdata), int64(len(c.dotdata)), minio.PutObjectOptions{
+		ContentType:          "application/octet-stream",
+		ServerSideEncryption: d.sse,
+	})
+	if err != nil {
+		log.Error(err, "Failed to upload pipeline dot data")
+		return
+	}
+
+	if imgdata != nil {
+		if _, err := d.mc.PutObject(context.Background(), d.outbucket, imgname, bytes.NewBuffer(imgdata), int64(len(imgdata)), minio.PutObjectOptions{
+			ContentType:          "application/octet-stream",
+			ServerSideEncryption: d.sse,
+		}); err != nil {
+			log.Error(err, "Failed to upload rendered image of dot graph")
+		}
+	}
+}
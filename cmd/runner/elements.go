@@ -17,11 +17,13 @@ limitations under the License.
 package main
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/tinyzimmer/go-glib/glib"
 	"github.com/tinyzimmer/go-gst/gst"
@@ -30,68 +32,153 @@ import (
 )
 
 func makeSrcElement(objCfg *pipelinesmeta.Object) (*gst.Element, error) {
-	elem, err := gst.NewElement("miniosrc")
+	elem, err := gst.NewElement(srcFactory(objCfg))
 	if err != nil {
 		return nil, err
 	}
+	if err := configureSrcElement(elem, objCfg); err != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+func configureSrcElement(elem *gst.Element, objCfg *pipelinesmeta.Object) error {
+	if isLocalObject(objCfg) {
+		log.Info("Configuring src element", "Location", objCfg.Name)
+		elem.SetProperty("location", objCfg.Name)
+		return nil
+	}
 
 	cfg := objCfg.Config.MinIO // TODO
-	log.Info("Creating src element", "Config", *cfg, "Key", objCfg.Name)
+	log.Info("Configuring src element", "Config", *cfg, "Key", objCfg.Name)
 
 	elem.SetProperty("endpoint", cfg.GetEndpoint())
 	elem.SetProperty("use-tls", cfg.GetSecure())
 	elem.SetProperty("region", cfg.GetRegion())
 	elem.SetProperty("bucket", cfg.GetBucket())
 	elem.SetProperty("key", objCfg.Name)
-	elem.SetProperty("access-key-id", os.Getenv(pipelinesmeta.MinIOSrcAccessKeyIDEnvVar))
-	elem.SetProperty("secret-access-key", os.Getenv(pipelinesmeta.MinIOSrcSecretAccessKeyEnvVar))
+	if objCfg.VersionID != "" {
+		elem.SetProperty("version-id", objCfg.VersionID)
+	}
+	if objCfg.ETag != "" {
+		elem.SetProperty("etag", objCfg.ETag)
+	}
+	// Credentials are passed by reference, the element resolves them from the environment
+	elem.SetProperty("access-key-id", "env:"+pipelinesmeta.MinIOSrcAccessKeyIDEnvVar)
+	elem.SetProperty("secret-access-key", "env:"+pipelinesmeta.MinIOSrcSecretAccessKeyEnvVar)
+	if cfg.Encryption.IsCustomerKey() {
+		elem.SetProperty("sse-customer-key", "env:"+pipelinesmeta.MinIOSrcCustomerKeyEnvVar)
+	}
 
-	rootCA, err := cfg.GetRootPEM()
+	caFile, err := writeRootCA(cfg, "/tmp/ca-src.crt")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if rootCA != nil {
-		if err := ioutil.WriteFile("/tmp/ca-src.crt", rootCA, 0644); err != nil {
-			return nil, err
-		}
-		elem.SetProperty("ca-cert-file", "/tmp/ca-src.crt")
+	if caFile != "" {
+		elem.SetProperty("ca-cert-file", caFile)
 	}
 
-	return elem, nil
+	return nil
 }
 
 func makeSinkElement(objCfg *pipelinesmeta.Object) (*gst.Element, *pipelinesmeta.GstElementConfig, error) {
-	elem, err := gst.NewElement("miniosink")
+	elem, err := gst.NewElement(sinkFactory(objCfg))
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := configureSinkElement(elem, objCfg); err != nil {
+		return nil, nil, err
+	}
+
+	elemcfg := &pipelinesmeta.GstElementConfig{}
+	elemcfg.SetPipelineName(elem.GetName())
+
+	return elem, elemcfg, nil
+}
+
+func configureSinkElement(elem *gst.Element, objCfg *pipelinesmeta.Object) error {
+	if isLocalObject(objCfg) {
+		log.Info("Configuring sink element", "Location", objCfg.Name)
+		if err := os.MkdirAll(filepath.Dir(objCfg.Name), 0755); err != nil {
+			return err
+		}
+		elem.SetProperty("location", objCfg.Name)
+		return nil
+	}
 
 	cfg := objCfg.Config.MinIO // TODO
-	log.Info("Creating sink element", "Config", *cfg, "Key", objCfg.Name)
+	log.Info("Configuring sink element", "Config", *cfg, "Key", objCfg.Name)
 
 	elem.SetProperty("endpoint", cfg.GetEndpoint())
 	elem.SetProperty("use-tls", cfg.GetSecure())
 	elem.SetProperty("region", cfg.GetRegion())
 	elem.SetProperty("bucket", cfg.GetBucket())
 	elem.SetProperty("key", objCfg.Name)
-	elem.SetProperty("access-key-id", os.Getenv(pipelinesmeta.MinIOSinkAccessKeyIDEnvVar))
-	elem.SetProperty("secret-access-key", os.Getenv(pipelinesmeta.MinIOSinkSecretAccessKeyEnvVar))
+	// Credentials are passed by reference, the element resolves them from the environment
+	elem.SetProperty("access-key-id", "env:"+pipelinesmeta.MinIOSinkAccessKeyIDEnvVar)
+	elem.SetProperty("secret-access-key", "env:"+pipelinesmeta.MinIOSinkSecretAccessKeyEnvVar)
+
+	for prop, value := range map[string]string{
+		"content-type":        cfg.ContentType,
+		"content-disposition": cfg.ContentDisposition,
+		"cache-control":       cfg.CacheControl,
+		"metadata":            cfg.GetEncodedMetadata(),
+		"tags":                cfg.GetEncodedTags(),
+		"storage-class":       cfg.StorageClass,
+	} {
+		if value != "" {
+			elem.SetProperty(prop, value)
+		}
+	}
 
-	rootCA, err := cfg.GetRootPEM()
+	if cfg.Encryption != nil {
+		elem.SetProperty("sse-type", string(cfg.Encryption.Type))
+		switch cfg.Encryption.Type {
+		case pipelinesmeta.EncryptionSSEKMS:
+			elem.SetProperty("sse-kms-key-id", cfg.Encryption.KMSKeyID)
+		case pipelinesmeta.EncryptionSSEC:
+			elem.SetProperty("sse-customer-key", "env:"+pipelinesmeta.MinIOSinkCustomerKeyEnvVar)
+		}
+	}
+
+	caFile, err := writeRootCA(cfg, "/tmp/ca-sink.crt")
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
-	if rootCA != nil {
-		if err := ioutil.WriteFile("/tmp/ca-sink.crt", rootCA, 0644); err != nil {
-			return nil, nil, err
-		}
-		elem.SetProperty("ca-cert-file", "/tmp/ca-sink.crt")
+	if caFile != "" {
+		elem.SetProperty("ca-cert-file", caFile)
 	}
 
-	elemcfg := &pipelinesmeta.GstElementConfig{}
-	elemcfg.SetPipelineName(elem.GetName())
+	return nil
+}
 
-	return elem, elemcfg, nil
+// srcFactory returns the name of the element factory for reading the given object.
+func srcFactory(objCfg *pipelinesmeta.Object) string {
+	if isLocalObject(objCfg) {
+		return "filesrc"
+	}
+	return "miniosrc"
+}
+
+// sinkFactory returns the name of the element factory for writing the given object.
+func sinkFactory(objCfg *pipelinesmeta.Object) string {
+	if isLocalObject(objCfg) {
+		return "filesink"
+	}
+	return "miniosink"
+}
+
+// writeRootCA writes the root CA configured for MinIO to the given path, and returns the path if there
+// was one.
+func writeRootCA(cfg *pipelinesmeta.MinIOConfig, path string) (string, error) {
+	rootCA, err := cfg.GetRootPEM()
+	if err != nil || rootCA == nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, rootCA, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
 func makeElement(cfg *pipelinesmeta.GstElementConfig) (*gst.Element, error) {
@@ -107,9 +194,13 @@ func makeElement(cfg *pipelinesmeta.GstElementConfig) (*gst.Element, error) {
 		if err != nil {
 			return nil, err
 		}
-		gval, err := goStringToGValueByType(propType, propValue)
+		val, err := propValue.GetValue()
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("Invalid value for property %q of %s: %s", propName, cfg.Name, err.Error())
+		}
+		gval, err := goStringToGValueByType(propType, serializePropertyValue(propType, val))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for property %q of %s: %s", propName, cfg.Name, err.Error())
 		}
 		if err := elem.SetPropertyValue(propName, gval); err != nil {
 			return nil, err
@@ -118,100 +209,75 @@ func makeElement(cfg *pipelinesmeta.GstElementConfig) (*gst.Element, error) {
 	return elem, nil
 }
 
+// serializePropertyValue converts a value decoded from an element configuration to the string
+// representation GStreamer deserializes for the property type t. Lists become arrays, or value
+// lists when that is the type of the property.
+func serializePropertyValue(t glib.Type, val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for idx, item := range v {
+			items[idx] = serializePropertyValue(t, item)
+		}
+		if isValueListType(t) {
+			return fmt.Sprintf("{ %s }", strings.Join(items, ", "))
+		}
+		return fmt.Sprintf("< %s >", strings.Join(items, ", "))
+	}
+	return fmt.Sprint(val)
+}
+
 func goStringToGValueByType(t glib.Type, s string) (*glib.Value, error) {
 	value, err := glib.ValueInit(t)
 	if err != nil {
 		return nil, err
 	}
-	switch t {
-
-	case glib.TYPE_CHAR:
-		i, err := strconv.ParseInt(s, 10, 8)
-		if err != nil {
-			return nil, err
-		}
-		value.SetSChar(int8(i))
-
-	case glib.TYPE_UCHAR:
-		i, err := strconv.ParseInt(s, 10, 8)
-		if err != nil {
-			return nil, err
-		}
-		value.SetUChar(uint8(i))
-
-	case glib.TYPE_BOOLEAN:
-		switch s {
-		case "true":
-			value.SetBool(true)
-		case "false":
-			value.SetBool(false)
-		default:
-			return nil, fmt.Errorf("Unrecognized bool value: %s", s)
-		}
-
-	case glib.TYPE_INT:
-		i, err := strconv.ParseInt(s, 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		value.SetInt(int(i))
+	// Enum, flags and object properties are registered as types deriving from
+	// the fundamental ones.
+	_, fundamental, err := value.Type()
+	if err != nil {
+		return nil, err
+	}
+	switch fundamental {
 
 	case glib.TYPE_ENUM:
-		// Need ENUM helpers in glib
-		return nil, errors.New("enums not implemented")
-
-	case glib.TYPE_INT64:
-		i, err := strconv.ParseInt(s, 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		value.SetInt64(int64(i))
-
-	case glib.TYPE_UINT:
-		i, err := strconv.ParseInt(s, 10, 64)
+		i, err := enumValueFromString(t, s)
 		if err != nil {
 			return nil, err
 		}
-		value.SetUInt(uint(i))
+		setEnumValue(value, i)
 
 	case glib.TYPE_FLAGS:
-		// Need helpers
-		return nil, errors.New("flags not implemented")
-
-	case glib.TYPE_UINT64:
-		i, err := strconv.ParseInt(s, 10, 64)
+		i, err := flagsValueFromString(t, s)
 		if err != nil {
 			return nil, err
 		}
-		value.SetUInt64(uint64(i))
+		setFlagsValue(value, i)
 
-	case glib.TYPE_FLOAT:
-		f, err := strconv.ParseFloat(s, 32)
+	case glib.TYPE_OBJECT:
+		// Assumes an element for now, such as the muxer of a splitmuxsink
+		elem, err := gst.NewElement(s)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("Could not create %s from %q: %s", t.Name(), s, err.Error())
 		}
-		value.SetFloat(float32(f))
-
-	case glib.TYPE_DOUBLE:
-		f, err := strconv.ParseFloat(s, 64)
-		if err != nil {
+		if err := setObjectValue(value, elem); err != nil {
 			return nil, err
 		}
-		value.SetDouble(float64(f))
-
-	case glib.TYPE_STRING:
-		value.SetString(s)
 
-	case glib.TYPE_BOXED:
-		// Assumes caps for now
-		caps := gst.NewCapsFromString(s)
-		if caps == nil {
-			return nil, fmt.Errorf("Could not parse provided caps to string: %s", s)
+	default:
+		// Everything else, including caps, fractions, structures and arrays, is left to the
+		// deserializers registered with GStreamer.
+		if err := deserializeValue(value, s); err != nil {
+			return nil, err
 		}
-		return caps.ToGValue(), nil
-
-		// need to handle other GST types like fractions
-
 	}
 	return value, nil
 }
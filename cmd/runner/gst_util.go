@@ -0,0 +1,179 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+/*
+#cgo pkg-config: gstreamer-1.0
+#include <stdlib.h>
+#include <gst/gst.h>
+
+static void setObjectProperty(GObject *obj, const gchar *name, gpointer value)
+{
+	g_object_set(obj, name, value, NULL);
+}
+
+static GEnumValue * enumValueAt(GEnumClass *klass, guint idx) { return &klass->values[idx]; }
+
+static GFlagsValue * flagsValueAt(GFlagsClass *klass, guint idx) { return &klass->values[idx]; }
+*/
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/tinyzimmer/go-glib/glib"
+	"github.com/tinyzimmer/go-gst/gst"
+)
+
+// This file contains helpers for GStreamer functionality not yet exposed by the bindings.
+
+func toCElement(elem *gst.Element) *C.GstElement { return (*C.GstElement)(elem.Unsafe()) }
+
+func toCPad(pad *gst.Pad) *C.GstPad { return (*C.GstPad)(pad.Unsafe()) }
+
+// requestPad requests a new pad from the given element using the given template name.
+// Nil is returned if the element could not provide one.
+func requestPad(elem *gst.Element, name string) *gst.Pad {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	pad := C.gst_element_get_request_pad(toCElement(elem), (*C.gchar)(cName))
+	if pad == nil {
+		return nil
+	}
+	return gst.FromGstPadUnsafeFull(unsafe.Pointer(pad))
+}
+
+// compatiblePad returns a pad of elem that pad can be linked to, requesting one if needed.
+// Nil is returned if the element has no compatible pad.
+func compatiblePad(elem *gst.Element, pad *gst.Pad) *gst.Pad {
+	compatible := C.gst_element_get_compatible_pad(toCElement(elem), toCPad(pad), nil)
+	if compatible == nil {
+		return nil
+	}
+	return gst.FromGstPadUnsafeFull(unsafe.Pointer(compatible))
+}
+
+// releaseRequestPad releases a pad previously retrieved with requestPad.
+func releaseRequestPad(elem *gst.Element, pad *gst.Pad) {
+	C.gst_element_release_request_pad(toCElement(elem), toCPad(pad))
+}
+
+// setElementProperty sets a property on elem that holds another element, such as the sink
+// of a splitmuxsink.
+func setElementProperty(elem *gst.Element, name string, value *gst.Element) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	C.setObjectProperty((*C.GObject)(elem.Unsafe()), (*C.gchar)(cName), (C.gpointer)(value.Unsafe()))
+}
+
+// enumValueFromString resolves s to a value of the enum type t, as found on the GParamSpec of
+// an element property. The value may be given by its nick, name or number.
+func enumValueFromString(t glib.Type, s string) (int, error) {
+	klass := (*C.GEnumClass)(C.g_type_class_ref(C.GType(t)))
+	defer C.g_type_class_unref(C.gpointer(klass))
+
+	s = strings.TrimSpace(s)
+	num, numErr := strconv.Atoi(s)
+	valid := make([]string, int(klass.n_values))
+	for idx := range valid {
+		val := C.enumValueAt(klass, C.guint(idx))
+		nick := C.GoString((*C.char)(unsafe.Pointer(val.value_nick)))
+		name := C.GoString((*C.char)(unsafe.Pointer(val.value_name)))
+		if s == nick || s == name || (numErr == nil && num == int(val.value)) {
+			return int(val.value), nil
+		}
+		valid[idx] = fmt.Sprintf("%s (%d)", nick, int(val.value))
+	}
+	return 0, fmt.Errorf("Invalid value %q for %s, valid values are: %s", s, t.Name(), strings.Join(valid, ", "))
+}
+
+// flagsValueFromString resolves s to a value of the flags type t, as found on the GParamSpec of
+// an element property. Multiple flags are separated by a "+" or "|" and each may be given by its
+// nick, name or number.
+func flagsValueFromString(t glib.Type, s string) (uint, error) {
+	klass := (*C.GFlagsClass)(C.g_type_class_ref(C.GType(t)))
+	defer C.g_type_class_unref(C.gpointer(klass))
+
+	var out uint
+Flags:
+	for _, flag := range strings.FieldsFunc(s, func(r rune) bool { return r == '+' || r == '|' }) {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+		if num, err := strconv.ParseUint(flag, 0, 32); err == nil {
+			out |= uint(num)
+			continue
+		}
+		valid := make([]string, int(klass.n_values))
+		for idx := range valid {
+			val := C.flagsValueAt(klass, C.guint(idx))
+			nick := C.GoString((*C.char)(unsafe.Pointer(val.value_nick)))
+			name := C.GoString((*C.char)(unsafe.Pointer(val.value_name)))
+			if flag == nick || flag == name {
+				out |= uint(val.value)
+				continue Flags
+			}
+			valid[idx] = fmt.Sprintf("%s (0x%x)", nick, uint(val.value))
+		}
+		return 0, fmt.Errorf("Invalid flag %q for %s, valid flags are: %s", flag, t.Name(), strings.Join(valid, ", "))
+	}
+	return out, nil
+}
+
+// setEnumValue sets the given enum value on a GValue initialized to an enum type.
+func setEnumValue(value *glib.Value, i int) {
+	C.g_value_set_enum((*C.GValue)(value.Unsafe()), C.gint(i))
+}
+
+// setFlagsValue sets the given flags on a GValue initialized to a flags type.
+func setFlagsValue(value *glib.Value, i uint) {
+	C.g_value_set_flags((*C.GValue)(value.Unsafe()), C.guint(i))
+}
+
+// setObjectValue sets the given element on a GValue initialized to an object type, provided the
+// element is an instance of that type.
+func setObjectValue(value *glib.Value, elem *gst.Element) error {
+	actual, _, err := value.Type()
+	if err != nil {
+		return err
+	}
+	if !elem.TypeFromInstance().IsA(actual) {
+		return fmt.Errorf("%s is not a %s", elem.GetName(), actual.Name())
+	}
+	C.g_value_set_object((*C.GValue)(value.Unsafe()), C.gpointer(elem.Unsafe()))
+	return nil
+}
+
+// deserializeValue sets value from its string representation using the deserializers registered
+// with GStreamer for its type.
+func deserializeValue(value *glib.Value, s string) error {
+	cStr := C.CString(s)
+	defer C.free(unsafe.Pointer(cStr))
+	if C.gst_value_deserialize((*C.GValue)(value.Unsafe()), (*C.gchar)(cStr)) == C.FALSE {
+		return fmt.Errorf("Could not deserialize %q to %s", s, value.TypeName())
+	}
+	return nil
+}
+
+// isValueListType returns true if t is the type of GstValueList.
+func isValueListType(t glib.Type) bool {
+	return C.GType(t) == C.gst_value_list_get_type()
+}
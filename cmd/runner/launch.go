@@ -0,0 +1,113 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// launchSinkPlaceholders maps the sink placeholders usable in launch descriptions to the
+// stream types of the sink objects they write to.
+var launchSinkPlaceholders = map[string]pipelinesmeta.StreamType{
+	pipelinesmeta.LaunchPlaceholderSink: pipelinesmeta.StreamTypeAll,
+	pipelinesmeta.LinkToVideoOut:        pipelinesmeta.StreamTypeVideo,
+	pipelinesmeta.LinkToAudioOut:        pipelinesmeta.StreamTypeAudio,
+}
+
+// buildPipelineFromLaunch parses the launch description in the pipeline configuration. The
+// placeholders referenced by the description are declared ahead of it as miniosrc and miniosink
+// elements, or filesrc and filesink for local files, so the parser links them like any other named
+// element, and they are configured for the source and sink objects once the pipeline is built.
+func buildPipelineFromLaunch(spec *pipelineSpec) (*gst.Pipeline, error) {
+	if len(spec.cfg.Elements) > 0 {
+		return nil, errors.New("The pipeline configuration cannot contain both elements and a launch description")
+	}
+	if len(spec.inputs) > 0 || spec.live != nil {
+		return nil, errors.New("Launch descriptions are only supported for pipelines reading a single source object")
+	}
+
+	desc := spec.cfg.Launch
+	if !referencesPlaceholder(desc, pipelinesmeta.LaunchPlaceholderSrc) {
+		return nil, fmt.Errorf("The launch description must read from the %q placeholder", pipelinesmeta.LaunchPlaceholderSrc)
+	}
+
+	declarations := []string{fmt.Sprintf("%s name=%s", srcFactory(spec.src), pipelinesmeta.LaunchPlaceholderSrc)}
+	sinks := make(map[string]*pipelinesmeta.Object)
+	for placeholder, streamType := range launchSinkPlaceholders {
+		if !referencesPlaceholder(desc, placeholder) {
+			continue
+		}
+		sinkobj := objectByStreamType(streamType, spec.sinks)
+		if sinkobj == nil {
+			if streamType == pipelinesmeta.StreamTypeAll {
+				return nil, errors.New("No sink configured for pipeline")
+			}
+			return nil, fmt.Errorf("No %s sink configured for pipeline", streamType)
+		}
+		factory := sinkFactory(sinkobj)
+		if spec.validate {
+			// Nothing is written while validating a pipeline
+			factory = "fakesink"
+		}
+		declarations = append(declarations, fmt.Sprintf("%s name=%s", factory, placeholder))
+		sinks[placeholder] = sinkobj
+	}
+	if len(sinks) == 0 {
+		return nil, errors.New("The launch description must write to at least one of the sink placeholders")
+	}
+
+	log.Info("Parsing launch description", "Description", desc)
+	pipeline, err := gst.NewPipelineFromString(strings.Join(append(declarations, desc), " "))
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := pipeline.GetElementByName(pipelinesmeta.LaunchPlaceholderSrc)
+	if err != nil {
+		return nil, err
+	}
+	if err := configureSrcElement(src, spec.src); err != nil {
+		return nil, err
+	}
+	if spec.validate {
+		return pipeline, nil
+	}
+	for placeholder, sinkobj := range sinks {
+		sink, err := pipeline.GetElementByName(placeholder)
+		if err != nil {
+			return nil, err
+		}
+		if err := configureSinkElement(sink, sinkobj); err != nil {
+			return nil, err
+		}
+	}
+
+	return pipeline, nil
+}
+
+// referencesPlaceholder returns true if the given launch description links to or from the
+// element with the given placeholder name.
+func referencesPlaceholder(desc, placeholder string) bool {
+	return regexp.MustCompile(`(^|[\s!])` + regexp.QuoteMeta(placeholder) + `\.`).MatchString(desc)
+}
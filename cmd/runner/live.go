@@ -0,0 +1,137 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// makeLiveSrcElement creates the source element for a network stream. The element exposes
+// its pads once the stream has been discovered, so a config is returned for linking peers
+// to it later.
+func makeLiveSrcElement(live *pipelinesmeta.LiveSourceConfig) (*gst.Element, *pipelinesmeta.GstElementConfig, error) {
+	elem, err := gst.NewElement("urisourcebin")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Info("Creating live src element", "URI", live.GetURI())
+	elem.SetProperty("uri", live.GetURI())
+
+	elemcfg := &pipelinesmeta.GstElementConfig{}
+	elemcfg.SetPipelineName(elem.GetName())
+
+	return elem, elemcfg, nil
+}
+
+// segmentRecorder records the streams of a live pipeline into rolling segments using a
+// splitmuxsink writing to a miniosink.
+type segmentRecorder struct {
+	pipeline *gst.Pipeline
+	splitmux *gst.Element
+	hasVideo bool
+}
+
+func newSegmentRecorder(pipeline *gst.Pipeline, sinkObjects []*pipelinesmeta.Object, segments *pipelinesmeta.SegmentConfig) (*segmentRecorder, error) {
+	sinkobj := objectByStreamType(pipelinesmeta.StreamTypeAll, sinkObjects)
+	if sinkobj == nil {
+		return nil, errors.New("No sink configured for pipeline")
+	}
+	splitmux, err := gst.NewElement("splitmuxsink")
+	if err != nil {
+		return nil, err
+	}
+	sink, _, err := makeSinkElement(sinkobj)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Creating segment recorder", "Muxer", segments.GetMuxer(), "Duration", segments.GetDuration())
+	splitmux.SetProperty("muxer-factory", segments.GetMuxer())
+	splitmux.SetProperty("max-size-time", uint64(segments.GetDuration().Nanoseconds()))
+	splitmux.SetProperty("send-keyframe-requests", true)
+	setElementProperty(splitmux, "sink", sink)
+
+	// The splitmuxsink sets the location of the miniosink to the key returned for each segment
+	cfg := sinkobj.Config.MinIO // TODO
+	splitmux.Connect("format-location", func(self *gst.Element, fragmentID uint) string {
+		key := cfg.GetSegmentKey(fragmentID, time.Now())
+		log.Info("Starting new segment", "Key", key)
+		return key
+	})
+
+	pipeline.Add(splitmux)
+	return &segmentRecorder{pipeline: pipeline, splitmux: splitmux}, nil
+}
+
+// input returns an element that a stream of the given type can be linked to. Each call adds
+// a queue feeding a new request pad on the splitmuxsink.
+func (s *segmentRecorder) input(streamType pipelinesmeta.StreamType) (*gst.Element, *pipelinesmeta.GstElementConfig, error) {
+	padName := "video"
+	if streamType == pipelinesmeta.StreamTypeAudio {
+		padName = "audio_%u"
+	} else if s.hasVideo {
+		return nil, nil, errors.New("Only one video stream can be recorded by a live pipeline")
+	}
+
+	queue, err := gst.NewElement("queue")
+	if err != nil {
+		return nil, nil, err
+	}
+	s.pipeline.Add(queue)
+
+	sinkpad := requestPad(s.splitmux, padName)
+	if sinkpad == nil {
+		return nil, nil, fmt.Errorf("Could not request a %s pad from the segment recorder", padName)
+	}
+	if ret := queue.GetStaticPad("src").Link(sinkpad); ret != gst.PadLinkOK {
+		return nil, nil, fmt.Errorf("Failed to link %s stream to the segment recorder: %s", streamType, ret.String())
+	}
+	if padName == "video" {
+		s.hasVideo = true
+	}
+
+	elemcfg := &pipelinesmeta.GstElementConfig{}
+	elemcfg.SetPipelineName(queue.GetName())
+	return queue, elemcfg, nil
+}
+
+var markConnected sync.Once
+
+// handleLiveMessage handles element messages on the bus of a live pipeline. When the first
+// segment is opened, data from the stream is being recorded and the pipeline is marked as
+// connected for its readiness probe.
+func handleLiveMessage(msg *gst.Message) {
+	st := msg.GetStructure()
+	if st == nil || st.Name() != "splitmuxsink-fragment-opened" {
+		return
+	}
+	markConnected.Do(func() {
+		log.Info("Receiving data from stream, marking pipeline as connected")
+		if err := ioutil.WriteFile(pipelinesmeta.LiveConnectedFile, []byte{}, 0644); err != nil {
+			log.Error(err, "Failed to write connected marker")
+		}
+	})
+}
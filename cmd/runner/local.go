@@ -0,0 +1,152 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+	pipelinetypes "github.com/tinyzimmer/gst-pipeline-operator/pkg/types"
+)
+
+// Flags for running a pipeline outside of Kubernetes. When no manifest is given the runner reads
+// its configuration from the environment set up by the controller.
+var (
+	manifestFile = flag.String("f", "", "A Transform or SplitTransform manifest to run locally instead of reading the job from the environment")
+	srcKey       = flag.String("key", "", "The key of the object to read from the source bucket of the manifest")
+	srcFile      = flag.String("file", "", "A local file to read instead of an object in the source bucket")
+	outputDir    = flag.String("output-dir", "", "A local directory to write the outputs to instead of the sink buckets")
+	validateOnly = flag.Bool("validate", false, "Only validate the pipeline, discarding its outputs")
+)
+
+// loadPipelineSpec returns the spec for the pipeline to run, from the manifest given on the command
+// line or otherwise from the environment.
+func loadPipelineSpec() (*pipelineSpec, error) {
+	flag.Parse()
+	if *manifestFile == "" {
+		return getPipelineSpec()
+	}
+	return getLocalPipelineSpec()
+}
+
+// getLocalPipelineSpec builds the spec for the manifest given on the command line. Objects without
+// a MinIO configuration are local files, named by their path.
+func getLocalPipelineSpec() (*pipelineSpec, error) {
+	if (*srcKey == "") == (*srcFile == "") {
+		return nil, errors.New("Exactly one of -key or -file must be given with a manifest")
+	}
+
+	pipeline, err := readPipelineManifest(*manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := pipeline.GetPipelineConfig()
+	if cfg == nil {
+		return nil, errors.New("Pipelines referencing a template cannot be run locally, the pipeline configuration must be given inline")
+	}
+
+	spec := &pipelineSpec{
+		cfg:      cfg,
+		validate: *validateOnly,
+		local:    true,
+	}
+
+	key := *srcKey
+	if *srcFile != "" {
+		if _, err := os.Stat(*srcFile); err != nil {
+			return nil, err
+		}
+		// Outputs are named after the file as if it were an object in the source bucket
+		key = filepath.Base(*srcFile)
+		spec.src = &pipelinesmeta.Object{
+			Name:       *srcFile,
+			Config:     &pipelinesmeta.SourceSinkConfig{},
+			StreamType: pipelinesmeta.StreamTypeAll,
+		}
+	} else {
+		srcConfig := pipeline.GetSrcConfig()
+		if srcConfig == nil || srcConfig.MinIO == nil {
+			return nil, errors.New("The manifest has no source configuration to read the key from")
+		}
+		spec.src = &pipelinesmeta.Object{
+			Name:       key,
+			Config:     srcConfig,
+			StreamType: pipelinesmeta.StreamTypeAll,
+		}
+	}
+
+	for _, sink := range pipeline.GetSinkObjects(key) {
+		if sink.Config == nil || sink.Config.MinIO == nil {
+			return nil, fmt.Errorf("The %s sink in the manifest has no MinIO configuration", sink.StreamType)
+		}
+		if *outputDir != "" {
+			sink = &pipelinesmeta.Object{
+				Name:       filepath.Join(*outputDir, filepath.FromSlash(sink.Name)),
+				Config:     &pipelinesmeta.SourceSinkConfig{},
+				StreamType: sink.StreamType,
+			}
+		}
+		spec.sinks = append(spec.sinks, sink)
+	}
+
+	return spec, nil
+}
+
+// readPipelineManifest reads a Transform or SplitTransform from the given YAML or JSON file.
+func readPipelineManifest(path string) (pipelinetypes.Pipeline, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	body, err := yaml.ToJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(body, &typeMeta); err != nil {
+		return nil, err
+	}
+
+	var pipeline pipelinetypes.Pipeline
+	switch pipelinesmeta.PipelineKind(typeMeta.Kind) {
+	case pipelinesv1.PipelineTransform:
+		pipeline = &pipelinesv1.Transform{}
+	case pipelinesv1.PipelineSplitTransform:
+		pipeline = &pipelinesv1.SplitTransform{}
+	default:
+		return nil, fmt.Errorf("Cannot run a %q locally, the manifest must be a Transform or SplitTransform", typeMeta.Kind)
+	}
+	if err := json.Unmarshal(body, pipeline); err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+// isLocalObject returns true if the given object is a local file rather than an object in MinIO.
+func isLocalObject(obj *pipelinesmeta.Object) bool {
+	return obj.Config == nil || obj.Config.MinIO == nil
+}
@@ -17,23 +17,16 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path"
-	"strings"
 	"time"
 
-	"github.com/goccy/go-graphviz"
-	"github.com/minio/minio-go/v7"
 	"github.com/tinyzimmer/go-glib/glib"
 	"github.com/tinyzimmer/go-gst/gst"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
-	"github.com/tinyzimmer/gst-pipeline-operator/pkg/util"
 )
 
 var log = zap.New(zap.UseDevMode(true)).WithName("gst-runner")
@@ -43,28 +36,110 @@ func init() { gst.Init(nil) }
 func main() {
 	mainLoop := glib.NewMainLoop(glib.MainContextDefault(), false)
 
-	cfg, srcobject, sinkobjects, err := getPipelineCfgAndObjects()
+	spec, err := loadPipelineSpec()
 	if err != nil {
-		log.Error(err, "Failed to retrieve job spec from environment")
+		log.Error(err, "Failed to retrieve job spec")
 		os.Exit(1)
 	}
+	cfg, srcobject := spec.cfg, spec.src
 
-	pipeline, err := buildPipelineFromCR(cfg, srcobject, sinkobjects)
+	if spec.validate {
+		os.Exit(runValidation(spec))
+	}
+
+	if srcobject != nil {
+		if err := verifySourceVersion(srcobject); err != nil {
+			log.Error(err, "Failed to verify the source object for the pipeline")
+			os.Exit(4)
+		}
+	}
+
+	if len(spec.inputs) > 0 {
+		if err := verifyInputObjects(spec.inputs); err != nil {
+			log.Error(err, "Failed to verify the input objects for the pipeline")
+			os.Exit(4)
+		}
+	}
+
+	result := newRunResult(spec)
+
+	if cfg.Discover != nil && srcobject != nil {
+		media, err := discoverSource(spec)
+		if err != nil {
+			// The pipeline may still be able to process what the discoverer could not make out
+			log.Error(err, "Failed to discover the contents of the source object")
+		} else {
+			result.setMedia(media)
+		}
+	}
+
+	pipeline, err := buildPipelineFromCR(spec)
 	if err != nil {
 		log.Error(err, "Failed to build pipeline from job spec")
 		os.Exit(2)
 	}
 
+	dot := newDotDumper(pipeline, cfg, srcobject)
+	wd := newWatchdog(pipeline, cfg)
+
+	var metrics *pipelineMetrics
+	if port := cfg.GetMetricsPort(); port != 0 {
+		metrics = newPipelineMetrics(pipeline)
+		startMetricsServer(pipeline, metrics, port)
+	}
+
 	pipeline.GetPipelineBus().AddWatch(func(msg *gst.Message) bool {
 		switch msg.Type() {
+		case gst.MessageElement:
+			if spec.live != nil {
+				handleLiveMessage(msg)
+			}
+		case gst.MessageTag:
+			result.addTags(msg.ParseTags())
+		case gst.MessageQoS, gst.MessageBuffering:
+			if metrics != nil {
+				metrics.handleMessage(msg)
+			}
+		case gst.MessageStateChanged:
+			// Only the pipeline itself, the graph is complete once it has changed state
+			if dot != nil && msg.Source() == pipeline.GetName() {
+				oldState, newState := msg.ParseStateChanged()
+				dot.capture(stateChangeReason(oldState, newState))
+			}
 		case gst.MessageEOS:
 			log.Info("Received EOS, setting pipeline state to NULL")
-			pipeline.BlockSetState(gst.StateNull)
+			wd.disarm()
+			if dot != nil {
+				dot.capture(dotReasonEOS)
+			}
+			result.finish(pipeline, pipelinesmeta.ExitReasonFinished, nil)
+			if err := wd.stop(); err != nil {
+				log.Error(err, "Pipeline failed to shut down")
+				result.fail(err)
+				if dot != nil {
+					dot.drain(cfg.GetShutdownTimeout())
+				}
+				result.write()
+				os.Exit(7)
+			}
 			mainLoop.Quit()
 			return false
 		case gst.MessageError:
 			err := msg.ParseError()
 			log.Error(err, err.DebugString())
+			wd.disarm()
+			if dot != nil {
+				dot.capture(dotReasonError)
+			}
+			result.finish(pipeline, pipelinesmeta.ExitReasonError, err)
+			// Stopping the sinks aborts their multipart uploads, so no parts are left in the bucket
+			if err := wd.stop(); err != nil {
+				log.Error(err, "Pipeline failed to shut down")
+			}
+			if dot != nil {
+				dot.drain(cfg.GetShutdownTimeout())
+			}
+			result.write()
 			os.Exit(3)
 		}
 
@@ -72,96 +147,31 @@ func main() {
 		return true
 	})
 
-	pipeline.BlockSetState(gst.StatePlaying)
+	handleTermination(pipeline, cfg, result, wd)
 
-	go func() {
-		var mc *minio.Client
-		var err error
-		var outbucket string
-		var outpath string
-		var g *graphviz.Graphviz
-
-		for cfg.DoDotDump() {
-			mc, err = util.GetMinIOClient(srcobject.Config.MinIO, util.MinIOSrcCredentialsFromEnv())
-			if err != nil {
-				log.Error(err, "Could not create src minio client for dot graph debugging")
-				mc = nil
-				break
-			}
-			outbucket = srcobject.Config.MinIO.GetBucket()
-			outpath = cfg.GetDotPath(srcobject.Name)
-			break
+	// Started ahead of the pipeline, which may already deadlock on its way to PLAYING
+	wd.watch(func(err error) {
+		log.Error(err, "Pipeline stalled")
+		if dot != nil {
+			dot.capture(dotReasonStall)
 		}
+		result.finish(pipeline, pipelinesmeta.ExitReasonError, err)
+		if err := wd.stop(); err != nil {
+			log.Error(err, "Pipeline failed to shut down")
+		}
+		if dot != nil {
+			dot.drain(cfg.GetShutdownTimeout())
+		}
+		result.write()
+		os.Exit(7)
+	})
 
-		for range time.NewTicker(cfg.GetDotInterval()).C {
-
-		DebugDotData:
-			for cfg.DoDotDump() && mc != nil {
-				if g == nil {
-					g = graphviz.New()
-				}
-				var dotname, imgname string
-				var dotdata, imgdata []byte
-
-				dotdata = []byte(pipeline.DebugBinToDotData(gst.DebugGraphShowAll))
-
-				if cfg.TimestampDotGraphs() {
-					ts := time.Now().UTC().Format(time.RFC3339)
-					dotname = path.Join(outpath, fmt.Sprintf("pipeline_%s.dot", ts))
-					if render := cfg.GetDotRenderFormat(); render != "" {
-						imgname = path.Join(outpath, fmt.Sprintf("pipeline_%s.%s", ts, strings.ToLower(render)))
-					}
-				} else {
-					dotname = path.Join(outpath, "pipeline.dot")
-					if render := cfg.GetDotRenderFormat(); render != "" {
-						imgname = path.Join(outpath, fmt.Sprintf("pipeline.%s", strings.ToLower(render)))
-					}
-				}
-
-				graph, err := graphviz.ParseBytes(dotdata)
-				if err != nil {
-					log.Error(err, "Failed to parse pipeline dot data")
-					break DebugDotData
-				}
-				var buf bytes.Buffer
-				switch strings.ToLower(cfg.GetDotRenderFormat()) {
-				case "png":
-					if err := g.Render(graph, graphviz.PNG, &buf); err != nil {
-						log.Error(err, "Failed to convert dotdata to PNG")
-						break DebugDotData
-					}
-					imgdata = buf.Bytes()
-				case "svg":
-					if err := g.Render(graph, graphviz.SVG, &buf); err != nil {
-						log.Error(err, "Failed to convert dotdata to SVG")
-						break DebugDotData
-					}
-					imgdata = buf.Bytes()
-				case "jpg":
-					if err := g.Render(graph, graphviz.JPG, &buf); err != nil {
-						log.Error(err, "Failed to convert dotdata to JPG")
-						break DebugDotData
-					}
-					imgdata = buf.Bytes()
-				}
-
-				_, err = mc.PutObject(context.Background(), outbucket, dotname, bytes.NewBuffer(dotdata), int64(len([]byte(dotdata))), minio.PutObjectOptions{
-					ContentType: "application/octet-stream",
-				})
-				if err != nil {
-					log.Error(err, "Failed to upload pipeline dot data")
-					break DebugDotData
-				}
-
-				if imgdata != nil {
-					if _, err := mc.PutObject(context.Background(), outbucket, imgname, bytes.NewBuffer(imgdata), int64(len(imgdata)), minio.PutObjectOptions{
-						ContentType: "application/octet-stream",
-					}); err != nil {
-						log.Error(err, "Failed to upload rendered image of dot graph")
-					}
-				}
+	pipeline.BlockSetState(gst.StatePlaying)
 
-				break DebugDotData
+	go func() {
+		for range time.NewTicker(cfg.GetDotInterval()).C {
+			if dot != nil {
+				dot.capture(dotReasonInterval)
 			}
 
 			posquery := gst.NewPositionQuery(gst.FormatTime)
@@ -181,25 +191,62 @@ func main() {
 
 	mainLoop.Run()
 
-	log.Info("Main loop has returned, ensuring pipeline has reached null state")
-	for pipeline.GetState() == gst.StatePlaying {
+	log.Info("Main loop has returned")
+	if dot != nil {
+		dot.drain(cfg.GetShutdownTimeout())
 	}
+	result.write()
+}
 
-	log.Info("Pipeline finished", "State", pipeline.GetState())
+// pipelineSpec holds the configuration read from the environment for building a pipeline.
+type pipelineSpec struct {
+	cfg *pipelinesmeta.PipelineConfig
+	// the source object, nil for live pipelines
+	src   *pipelinesmeta.Object
+	sinks []*pipelinesmeta.Object
+	// the objects read in order by pipelines with more than one input
+	inputs []*pipelinesmeta.Object
+	// the stream source and segment configuration for live pipelines
+	live     *pipelinesmeta.LiveSourceConfig
+	segments *pipelinesmeta.SegmentConfig
+	// whether the pipeline is only being validated, in which case outputs are discarded
+	validate bool
+	// whether the pipeline was given on the command line instead of by the controller
+	local bool
 }
 
-func getPipelineCfgAndObjects() (cfg *pipelinesmeta.PipelineConfig, src *pipelinesmeta.Object, sinks []*pipelinesmeta.Object, err error) {
-	cfg = &pipelinesmeta.PipelineConfig{}
-	src = &pipelinesmeta.Object{}
-	sinks = []*pipelinesmeta.Object{}
-	if err = json.Unmarshal([]byte(os.Getenv(pipelinesmeta.JobPipelineConfigEnvVar)), cfg); err != nil {
-		return
+func getPipelineSpec() (*pipelineSpec, error) {
+	spec := &pipelineSpec{
+		cfg:      &pipelinesmeta.PipelineConfig{},
+		sinks:    []*pipelinesmeta.Object{},
+		validate: os.Getenv(pipelinesmeta.JobValidateEnvVar) == "true",
+	}
+	if err := json.Unmarshal([]byte(os.Getenv(pipelinesmeta.JobPipelineConfigEnvVar)), spec.cfg); err != nil {
+		return nil, err
 	}
-	if err = json.Unmarshal([]byte(os.Getenv(pipelinesmeta.JobSrcObjectsEnvVar)), src); err != nil {
-		return
+	if err := json.Unmarshal([]byte(os.Getenv(pipelinesmeta.JobSinkObjectsEnvVar)), &spec.sinks); err != nil {
+		return nil, err
 	}
-	if err = json.Unmarshal([]byte(os.Getenv(pipelinesmeta.JobSinkObjectsEnvVar)), &sinks); err != nil {
-		return
+	if rawLive := os.Getenv(pipelinesmeta.JobLiveSourceEnvVar); rawLive != "" {
+		spec.live = &pipelinesmeta.LiveSourceConfig{}
+		if err := json.Unmarshal([]byte(rawLive), spec.live); err != nil {
+			return nil, err
+		}
+		if rawSegments := os.Getenv(pipelinesmeta.JobSegmentsEnvVar); rawSegments != "" {
+			if err := json.Unmarshal([]byte(rawSegments), &spec.segments); err != nil {
+				return nil, err
+			}
+		}
+		return spec, nil
+	}
+	spec.src = &pipelinesmeta.Object{}
+	if err := json.Unmarshal([]byte(os.Getenv(pipelinesmeta.JobSrcObjectsEnvVar)), spec.src); err != nil {
+		return nil, err
+	}
+	if rawInputs := os.Getenv(pipelinesmeta.JobInputObjectsEnvVar); rawInputs != "" {
+		if err := json.Unmarshal([]byte(rawInputs), &spec.inputs); err != nil {
+			return nil, err
+		}
 	}
-	return
+	return spec, nil
 }
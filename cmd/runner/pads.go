@@ -0,0 +1,302 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// Media types used for routing dynamically added pads.
+const (
+	mediaTypeVideo    = "video"
+	mediaTypeAudio    = "audio"
+	mediaTypeSubtitle = "subtitle"
+)
+
+// routeDynamicPad links a src pad added to the element of cfg to one of its peers. Peers whose
+// branch handles the same media type as the pad are tried first, followed by peers whose branch
+// accepts any media. An error is returned if no peer could be linked.
+func routeDynamicPad(pipeline *gst.Pipeline, cfg *pipelinesmeta.GstElementConfig, srcpad *gst.Pad) error {
+	if srcpad.IsLinked() || srcpad.Direction() != gst.PadDirectionSource {
+		return nil
+	}
+	// The pad targeted by a goto is linked by its own handler, any further pads matching
+	// the target are routed like the rest
+	for _, target := range cfg.GetPadTargets() {
+		if padMatches(srcpad, target) && claimPadTarget(srcpad.GetParentElement(), target, srcpad) {
+			return nil
+		}
+	}
+
+	caps := srcpad.GetCurrentCaps()
+	if caps == nil {
+		caps = srcpad.QueryCaps(nil)
+	}
+	padMedia := mediaTypeOfCaps(caps)
+
+	for _, exact := range []bool{true, false} {
+		for _, peer := range cfg.GetPeers() {
+			peerElem, err := elementForPipeline(pipeline, peer.GstElementConfig)
+			if err != nil {
+				return err
+			}
+			branchMedia := peerMediaType(peerElem, peer.Pad)
+			if exact && (padMedia == "" || branchMedia != padMedia) {
+				continue
+			}
+			if !exact && branchMedia != "" {
+				continue
+			}
+			peersink, err := sinkPadFor(peerElem, peer.Pad)
+			if err != nil {
+				return err
+			}
+			if !srcpad.CanLink(peersink) {
+				releaseIfRequested(peerElem, peersink)
+				continue
+			}
+			if ret := srcpad.Link(peersink); ret != gst.PadLinkOK {
+				releaseIfRequested(peerElem, peersink)
+				continue
+			}
+			return nil
+		}
+	}
+
+	desc := "unknown caps"
+	if caps != nil {
+		desc = caps.String()
+	}
+	return fmt.Errorf("No compatible destination for pad %s of %s with %s", srcpad.GetName(), cfg.Name, desc)
+}
+
+// peerMediaType returns the media type handled by the branch starting at the sink pad of elem
+// with the given name. Elements accepting any caps, such as queues, are walked past until one
+// restricts its input. An empty string is returned if the branch accepts any media.
+func peerMediaType(elem *gst.Element, padName string) string {
+	if padName != "" {
+		if tmpl := padTemplateFor(elem, gst.PadDirectionSink, padName); tmpl != nil {
+			return mediaTypeOfCaps(tmpl.Caps())
+		}
+		if pad := elem.GetStaticPad(padName); pad != nil {
+			return mediaTypeOfCaps(pad.GetPadTemplateCaps())
+		}
+		return ""
+	}
+	// Guard against loops in misconfigured pipelines
+	for depth := 0; elem != nil && depth < 32; depth++ {
+		sinkpad := elem.GetStaticPad("sink")
+		if sinkpad == nil {
+			return ""
+		}
+		if media := mediaTypeOfCaps(sinkpad.GetPadTemplateCaps()); media != "" {
+			return media
+		}
+		srcpad := elem.GetStaticPad("src")
+		if srcpad == nil {
+			return ""
+		}
+		peer := srcpad.GetPeer()
+		if peer == nil {
+			return ""
+		}
+		elem = peer.GetParentElement()
+	}
+	return ""
+}
+
+// mediaTypeOfCaps returns the media type shared by all structures in caps, or an empty string
+// if the caps are any or describe more than one media type.
+func mediaTypeOfCaps(caps *gst.Caps) string {
+	if caps == nil || caps.IsAny() || caps.GetSize() == 0 {
+		return ""
+	}
+	var media string
+	for idx := 0; idx < caps.GetSize(); idx++ {
+		this := mediaTypeOfStructure(caps.GetStructureAt(idx).Name())
+		if this == "" || (media != "" && this != media) {
+			return ""
+		}
+		media = this
+	}
+	return media
+}
+
+func mediaTypeOfStructure(name string) string {
+	switch {
+	case strings.HasPrefix(name, "video/"), strings.HasPrefix(name, "image/"):
+		return mediaTypeVideo
+	case strings.HasPrefix(name, "audio/"):
+		return mediaTypeAudio
+	case strings.HasPrefix(name, "text/"), strings.HasPrefix(name, "subpicture/"),
+		strings.HasPrefix(name, "application/x-subtitle"), name == "application/x-ssa", name == "application/x-ass":
+		return mediaTypeSubtitle
+	}
+	return ""
+}
+
+// linkFromPad links the src pad or pad template of elem with the given name to sink. Request
+// pads are requested immediately, while sometimes pads are linked once they are added. Only the
+// first pad added for a template is linked, further ones are left to the peers of cfg, and are
+// reported as an error if there are none.
+func linkFromPad(elem *gst.Element, cfg *pipelinesmeta.GstElementConfig, name string, sink *gst.Element, sinkPad string) error {
+	if srcpad := elem.GetStaticPad(name); srcpad != nil {
+		return linkPad(srcpad, sink, sinkPad)
+	}
+
+	tmpl := padTemplateFor(elem, gst.PadDirectionSource, name)
+	if tmpl == nil {
+		return fmt.Errorf("%s has no src pad or pad template named %q", elem.GetName(), name)
+	}
+
+	if tmpl.Presence() == gst.PadPresenceRequest {
+		srcpad := requestPad(elem, name)
+		if srcpad == nil {
+			return fmt.Errorf("%s did not provide a src pad for %q", elem.GetName(), name)
+		}
+		return linkPad(srcpad, sink, sinkPad)
+	}
+
+	elem.Connect("pad-added", func(self *gst.Element, srcpad *gst.Pad) {
+		if srcpad.Direction() != gst.PadDirectionSource || !padMatches(srcpad, name) {
+			return
+		}
+		if !claimPadTarget(self, name, srcpad) {
+			if cfg == nil || len(cfg.GetPeers()) == 0 {
+				err := fmt.Errorf("Pad %s of %s matches %q, which is already linked to %s", srcpad.GetName(), self.GetName(), name, sink.GetName())
+				self.ErrorMessage(gst.DomainStream, gst.StreamErrorFailed, err.Error(), "")
+			}
+			return
+		}
+		if err := linkPad(srcpad, sink, sinkPad); err != nil {
+			self.ErrorMessage(gst.DomainLibrary, gst.LibraryErrorFailed, err.Error(), "")
+		}
+	})
+	return nil
+}
+
+// padClaims holds the name of the pad linked for each pad target, keyed by the element and the
+// target. Pads may be added from different streaming threads.
+var padClaims = struct {
+	sync.Mutex
+	pads map[string]string
+}{pads: make(map[string]string)}
+
+// claimPadTarget returns true if srcpad is the pad linked for the target of elem with the given
+// name, claiming the target for it if no other pad did yet.
+func claimPadTarget(elem *gst.Element, target string, srcpad *gst.Pad) bool {
+	key := elem.GetName() + "." + target
+	padClaims.Lock()
+	defer padClaims.Unlock()
+	claimed, ok := padClaims.pads[key]
+	if !ok {
+		padClaims.pads[key] = srcpad.GetName()
+		return true
+	}
+	return claimed == srcpad.GetName()
+}
+
+// linkPad links srcpad to the sink pad or pad template of sink with the given name. When no name
+// is given, a compatible pad is picked from sink.
+func linkPad(srcpad *gst.Pad, sink *gst.Element, name string) error {
+	var sinkpad *gst.Pad
+	if name == "" {
+		if sinkpad = compatiblePad(sink, srcpad); sinkpad == nil {
+			return fmt.Errorf("%s has no sink pad compatible with %s", sink.GetName(), srcpad.GetName())
+		}
+	} else {
+		var err error
+		if sinkpad, err = sinkPadFor(sink, name); err != nil {
+			return err
+		}
+	}
+	if ret := srcpad.Link(sinkpad); ret != gst.PadLinkOK {
+		releaseIfRequested(sink, sinkpad)
+		return fmt.Errorf("Could not link %s to %s:%s: %s", srcpad.GetName(), sink.GetName(), sinkpad.GetName(), ret.String())
+	}
+	return nil
+}
+
+// sinkPadFor returns the sink pad of elem with the given name, requesting one if the name refers
+// to a request pad template. When no name is given, the static sink pad is returned.
+func sinkPadFor(elem *gst.Element, name string) (*gst.Pad, error) {
+	if name == "" {
+		if pad := elem.GetStaticPad("sink"); pad != nil {
+			return pad, nil
+		}
+		return nil, fmt.Errorf("%s does not have a static sink pad", elem.GetName())
+	}
+	if pad := elem.GetStaticPad(name); pad != nil {
+		return pad, nil
+	}
+	if tmpl := padTemplateFor(elem, gst.PadDirectionSink, name); tmpl != nil && tmpl.Presence() == gst.PadPresenceRequest {
+		if pad := requestPad(elem, name); pad != nil {
+			return pad, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no sink pad or request pad template named %q", elem.GetName(), name)
+}
+
+// releaseIfRequested releases pad back to elem if it was created from a request pad template.
+func releaseIfRequested(elem *gst.Element, pad *gst.Pad) {
+	if tmpl := pad.GetPadTemplate(); tmpl != nil && tmpl.Presence() == gst.PadPresenceRequest {
+		releaseRequestPad(elem, pad)
+	}
+}
+
+// padTemplateFor returns the pad template of elem in the given direction that either has the
+// given name, or would produce a pad with that name.
+func padTemplateFor(elem *gst.Element, direction gst.PadDirection, name string) *gst.PadTemplate {
+	for _, tmpl := range elem.GetPadTemplates() {
+		if tmpl.Direction() == direction && templateMatches(tmpl.Name(), name) {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// padMatches returns true if pad has the given name or was created from a template with it.
+func padMatches(pad *gst.Pad, name string) bool {
+	if pad.GetName() == name {
+		return true
+	}
+	tmpl := pad.GetPadTemplate()
+	return tmpl != nil && tmpl.Name() == name
+}
+
+var templateConversions = strings.NewReplacer(`%u`, `[0-9]+`, `%d`, `-?[0-9]+`, `%s`, `.+`)
+
+// templateMatches returns true if name is the name of the template, or a pad name the
+// template would produce (e.g. "audio_0" for "audio_%u").
+func templateMatches(tmplName, name string) bool {
+	if tmplName == name {
+		return true
+	}
+	if !strings.Contains(tmplName, "%") {
+		return false
+	}
+	expr := "^" + templateConversions.Replace(regexp.QuoteMeta(tmplName)) + "$"
+	match, err := regexp.MatchString(expr, name)
+	return err == nil && match
+}
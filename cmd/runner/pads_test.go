@@ -0,0 +1,51 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestTemplateMatches(t *testing.T) {
+	tests := []struct {
+		tmplName string
+		name     string
+		expected bool
+	}{
+		{tmplName: "src", name: "src", expected: true},
+		{tmplName: "src", name: "src_0", expected: false},
+		{tmplName: "audio_%u", name: "audio_%u", expected: true},
+		{tmplName: "audio_%u", name: "audio_0", expected: true},
+		{tmplName: "audio_%u", name: "audio_12", expected: true},
+		{tmplName: "audio_%u", name: "audio_-1", expected: false},
+		{tmplName: "audio_%u", name: "audio_", expected: false},
+		{tmplName: "audio_%u", name: "video_0", expected: false},
+		{tmplName: "audio_%u", name: "audio_0_1", expected: false},
+		{tmplName: "src_%d", name: "src_-1", expected: true},
+		{tmplName: "src_%d", name: "src_a", expected: false},
+		{tmplName: "src_%s", name: "src_main", expected: true},
+		{tmplName: "src_%u_%u", name: "src_1_2", expected: true},
+		{tmplName: "private.%u", name: "private.0", expected: true},
+		{tmplName: "private.%u", name: "privateX0", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmplName+"/"+tt.name, func(t *testing.T) {
+			if matched := templateMatches(tt.tmplName, tt.name); matched != tt.expected {
+				t.Errorf("Expected templateMatches(%q, %q) to be %v, got %v", tt.tmplName, tt.name, tt.expected, matched)
+			}
+		})
+	}
+}
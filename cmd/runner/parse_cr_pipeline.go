@@ -24,41 +24,102 @@ import (
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
 )
 
-func buildPipelineFromCR(cfg *pipelinesmeta.PipelineConfig, srcObject *pipelinesmeta.Object, sinkObjects []*pipelinesmeta.Object) (*gst.Pipeline, error) {
-	// Create a new pipeline
-	pipeline, err := gst.NewPipeline("")
-	if err != nil {
-		return nil, err
+func buildPipelineFromCR(spec *pipelineSpec) (*gst.Pipeline, error) {
+	// Launch descriptions are handed to the GStreamer parser
+	if spec.cfg.Launch != "" {
+		return buildPipelineFromLaunch(spec)
 	}
 
-	pipelineCfg := cfg.GetElements()
-
-	// Create the source element
-	src, err := makeSrcElement(srcObject)
+	// Create a new pipeline
+	pipeline, err := gst.NewPipeline("")
 	if err != nil {
 		return nil, err
 	}
 
-	pipeline.Add(src)
+	pipelineCfg := spec.cfg.GetElements()
 
-	var last *gst.Element = src
+	var last *gst.Element
 	var lastCfg *pipelinesmeta.GstElementConfig
+	var lastPad string
 	var staticSinks bool
 
+	switch {
+	case len(spec.inputs) > 0:
+		// Create the sources for each input and the concat elements they feed. The
+		// pipeline configuration picks up from there with a goto.
+		pipelineCfg, err = addConcatSources(pipeline, pipelineCfg, spec.inputs)
+		if err != nil {
+			return nil, err
+		}
+	case spec.live != nil:
+		// Create the source element for the stream
+		src, srcCfg, err := makeLiveSrcElement(spec.live)
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Add(src)
+		last, lastCfg = src, srcCfg
+	default:
+		// Create the source element
+		src, err := makeSrcElement(spec.src)
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Add(src)
+		last = src
+	}
+
+	// Live pipelines write all streams to a single segment recorder
+	var recorder *segmentRecorder
+	makeSink := func(streamType pipelinesmeta.StreamType) (*gst.Element, *pipelinesmeta.GstElementConfig, error) {
+		if spec.live != nil {
+			if recorder == nil {
+				if recorder, err = newSegmentRecorder(pipeline, spec.sinks, spec.segments); err != nil {
+					return nil, nil, err
+				}
+			}
+			return recorder.input(streamType)
+		}
+		sinkobj := objectByStreamType(streamType, spec.sinks)
+		if sinkobj == nil {
+			if streamType == pipelinesmeta.StreamTypeAll {
+				return nil, nil, errors.New("No sink configured for pipeline")
+			}
+			return nil, nil, fmt.Errorf("No %s sink configured for pipeline", streamType)
+		}
+		if spec.validate {
+			// Nothing is written while validating a pipeline
+			return makeValidationSink(pipeline)
+		}
+		sink, sinkCfg, err := makeSinkElement(sinkobj)
+		if err != nil {
+			return nil, nil, err
+		}
+		pipeline.Add(sink)
+		return sink, sinkCfg, nil
+	}
+
 	for _, elementCfg := range pipelineCfg {
 
+		// Concat pipelines have no single source to start from
+		if last == nil && elementCfg.GoTo == "" {
+			return nil, fmt.Errorf("Element %q has nothing to link from, the pipeline must begin with a goto", elementCfg.Name)
+		}
+
 		// If we are jumping in the pipeline - set the last pointers to the appropriate element and config
 		if elementCfg.GoTo != "" {
 			// We are jumping in the pipeline
-			lastCfg = pipelineCfg.GetByAlias(elementCfg.GoTo)
+			alias, pad := elementCfg.GetGoTo()
+			lastCfg = pipelineCfg.GetByAlias(alias)
 			if lastCfg == nil {
-				return nil, fmt.Errorf("No configuration referenced by alias %s", elementCfg.GoTo)
+				return nil, fmt.Errorf("No configuration referenced by alias %s", alias)
 			}
 			// Set the last element to this one
 			last, err = elementForPipeline(pipeline, lastCfg)
 			if err != nil {
 				return nil, err
 			}
+			lastPad = pad
 			continue
 		}
 
@@ -69,43 +130,38 @@ func buildPipelineFromCR(cfg *pipelinesmeta.PipelineConfig, srcObject *pipelines
 			var thisElem *gst.Element
 			var thisCfg *pipelinesmeta.GstElementConfig
 			var err error
-			if elementCfg.LinkTo == pipelinesmeta.LinkToVideoOut {
+			alias, pad := elementCfg.GetLinkTo()
+			if alias == pipelinesmeta.LinkToVideoOut {
 				// Check if this is a split pipeline and we are creating a sink for video
 				staticSinks = true
-				sinkobj := objectByStreamType(pipelinesmeta.StreamTypeVideo, sinkObjects)
-				if sinkobj == nil {
-					return nil, errors.New("No video sink configured for pipeline")
-				}
-				thisElem, thisCfg, err = makeSinkElement(sinkobj)
+				thisElem, thisCfg, err = makeSink(pipelinesmeta.StreamTypeVideo)
 				if err != nil {
 					return nil, err
 				}
-				pipeline.Add(thisElem)
-			} else if elementCfg.LinkTo == pipelinesmeta.LinkToAudioOut {
+			} else if alias == pipelinesmeta.LinkToAudioOut {
 				// Check if this is a split pipeline and we are creating a sink for audio
 				staticSinks = true
-				sinkobj := objectByStreamType(pipelinesmeta.StreamTypeAudio, sinkObjects)
-				if sinkobj == nil {
-					return nil, errors.New("No audio sink configured for pipeline")
-				}
-				thisElem, thisCfg, err = makeSinkElement(sinkobj)
+				thisElem, thisCfg, err = makeSink(pipelinesmeta.StreamTypeAudio)
 				if err != nil {
 					return nil, err
 				}
-				pipeline.Add(thisElem)
 			} else {
-				thisCfg = pipelineCfg.GetByAlias(elementCfg.LinkTo)
+				thisCfg = pipelineCfg.GetByAlias(alias)
+				if thisCfg == nil {
+					return nil, fmt.Errorf("No configuration referenced by alias %s", alias)
+				}
 				thisElem, err = elementForPipeline(pipeline, thisCfg)
 				if err != nil {
 					return nil, err
 				}
 			}
-			if err := linkLast(pipeline, last, lastCfg, thisElem, thisCfg); err != nil {
+			if err := linkLast(pipeline, last, lastCfg, lastPad, thisElem, thisCfg, pad); err != nil {
 				return nil, err
 			}
 
 			last = thisElem
 			lastCfg = thisCfg
+			lastPad = ""
 			continue
 		}
 
@@ -116,27 +172,23 @@ func buildPipelineFromCR(cfg *pipelinesmeta.PipelineConfig, srcObject *pipelines
 			return nil, err
 		}
 
-		if err := linkLast(pipeline, last, lastCfg, element, elementCfg); err != nil {
+		if err := linkLast(pipeline, last, lastCfg, lastPad, element, elementCfg, ""); err != nil {
 			return nil, err
 		}
 
 		last = element
 		lastCfg = elementCfg
+		lastPad = ""
 	}
 
 	// If we did not add static sinks while building the pipeline (i.e. this is a regular Transform pipeline)
 	// then create a link to the assumed only sink object
 	if !staticSinks {
-		sinkobj := objectByStreamType(pipelinesmeta.StreamTypeAll, sinkObjects)
-		if sinkobj == nil {
-			return nil, errors.New("No sink configured for pipeline")
-		}
-		sink, sinkCfg, err := makeSinkElement(sinkobj)
+		sink, sinkCfg, err := makeSink(pipelinesmeta.StreamTypeAll)
 		if err != nil {
 			return nil, err
 		}
-		pipeline.Add(sink)
-		if err := linkLast(pipeline, last, lastCfg, sink, sinkCfg); err != nil {
+		if err := linkLast(pipeline, last, lastCfg, lastPad, sink, sinkCfg, ""); err != nil {
 			return nil, err
 		}
 	}
@@ -144,46 +196,34 @@ func buildPipelineFromCR(cfg *pipelinesmeta.PipelineConfig, srcObject *pipelines
 	return pipeline, nil
 }
 
-func linkLast(pipeline *gst.Pipeline, last *gst.Element, lastCfg *pipelinesmeta.GstElementConfig, element *gst.Element, elementCfg *pipelinesmeta.GstElementConfig) error {
+func linkLast(pipeline *gst.Pipeline, last *gst.Element, lastCfg *pipelinesmeta.GstElementConfig, lastPad string, element *gst.Element, elementCfg *pipelinesmeta.GstElementConfig, elementPad string) error {
+	// If a src pad was targeted by a goto, link from it
+	if lastPad != "" {
+		if lastCfg != nil {
+			lastCfg.AddPadTarget(lastPad)
+		}
+		return linkFromPad(last, lastCfg, lastPad, element, elementPad)
+	}
+
 	// If the last element has a static src pad, link it to this element
 	// and continue
 	if srcpad := last.GetStaticPad("src"); srcpad != nil {
+		if elementPad != "" {
+			return linkPad(srcpad, element, elementPad)
+		}
 		return last.Link(element)
 	}
 
-	// The last element provides dynamic src pads (we hope - user will find out quick if they messed up)
-	lastCfg.AddPeer(elementCfg)
-	// weakLastCfg := lastCfg
-	last.Connect("no-more-pads", func(self *gst.Element) {
-		pads, err := self.GetPads()
-		if err != nil {
-			self.ErrorMessage(gst.DomainLibrary, gst.LibraryErrorFailed, err.Error(), "")
-			return
-		}
-	Pads:
-		for _, srcpad := range pads {
-			// Skip already linked and non-src pads
-			if srcpad.IsLinked() || srcpad.Direction() != gst.PadDirectionSource {
-				continue Pads
-			}
-			for _, peer := range lastCfg.GetPeers() {
-				peerElem, err := elementForPipeline(pipeline, peer)
-				if err != nil {
-					self.ErrorMessage(gst.DomainLibrary, gst.LibraryErrorFailed, err.Error(), "")
-					return
-				}
-				peersink := peerElem.GetStaticPad("sink")
-				if peersink == nil {
-					self.ErrorMessage(gst.DomainLibrary, gst.LibraryErrorFailed, fmt.Sprintf("peer %s does not have a static sink pad", peer.Name), "")
-					return
-				}
-				if srcpad.CanLink(peersink) {
-					srcpad.Link(peersink)
-					continue Pads
-				}
+	// The last element provides dynamic src pads (we hope - user will find out quick if they messed up).
+	// They are routed to the registered peers as they are added.
+	lastCfg.AddPeer(elementCfg, elementPad)
+	if len(lastCfg.GetPeers()) == 1 {
+		last.Connect("pad-added", func(self *gst.Element, srcpad *gst.Pad) {
+			if err := routeDynamicPad(pipeline, lastCfg, srcpad); err != nil {
+				self.ErrorMessage(gst.DomainStream, gst.StreamErrorWrongType, err.Error(), "")
 			}
-		}
-	})
+		})
+	}
 
 	return nil
 }
@@ -215,3 +255,14 @@ func objectByStreamType(t pipelinesmeta.StreamType, objs []*pipelinesmeta.Object
 	}
 	return nil
 }
+
+func makeValidationSink(pipeline *gst.Pipeline) (*gst.Element, *pipelinesmeta.GstElementConfig, error) {
+	sink, err := gst.NewElement("fakesink")
+	if err != nil {
+		return nil, nil, err
+	}
+	pipeline.Add(sink)
+	sinkCfg := &pipelinesmeta.GstElementConfig{}
+	sinkCfg.SetPipelineName(sink.GetName())
+	return sink, sinkCfg, nil
+}
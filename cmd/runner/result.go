@@ -0,0 +1,351 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/tinyzimmer/go-gst/gst"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	"github.com/tinyzimmer/gst-pipeline-operator/pkg/util"
+)
+
+// maxTagLength is the length tag values are truncated to in the result.
+const maxTagLength = 256
+
+// runResult collects the result of a pipeline run as the pipeline progresses.
+type runResult struct {
+	spec  *pipelineSpec
+	start time.Time
+
+	mu         sync.Mutex
+	terminated bool
+	result     *pipelinesmeta.JobResult
+	tags       map[string]string
+	media      *pipelinesmeta.MediaInfo
+}
+
+func newRunResult(spec *pipelineSpec) *runResult {
+	return &runResult{
+		spec:  spec,
+		start: time.Now(),
+		tags:  make(map[string]string),
+	}
+}
+
+// markTerminated records that the run was interrupted by a signal, so that it is not reported as
+// finished when the pipeline is drained.
+func (r *runResult) markTerminated() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.terminated = true
+}
+
+// setMedia records the contents discovered in the source object.
+func (r *runResult) setMedia(media *pipelinesmeta.MediaInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.media = media
+}
+
+// addTags records the tags posted by elements of the pipeline. Values that have no simple string
+// form, such as images, are skipped.
+func (r *runResult) addTags(tags *gst.TagList) {
+	if tags == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tags.ForEach(func(tagList *gst.TagList, tag gst.Tag) {
+		var value string
+		switch v := tagList.GetValueIndex(tag, 0).(type) {
+		case string:
+			value = v
+		case bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+			value = fmt.Sprint(v)
+		default:
+			return
+		}
+		if len(value) > maxTagLength {
+			value = value[:maxTagLength]
+		}
+		r.tags[string(tag)] = value
+	})
+}
+
+// finish records how the run ended along with the state of the pipeline. It must be called before
+// the pipeline is brought to NULL, since the caps negotiated for the outputs are cleared then. Only
+// the first call has any effect.
+func (r *runResult) finish(pipeline *gst.Pipeline, reason pipelinesmeta.ExitReason, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.result != nil {
+		return
+	}
+	if r.terminated && reason == pipelinesmeta.ExitReasonFinished {
+		reason = pipelinesmeta.ExitReasonTerminated
+	}
+	result := &pipelinesmeta.JobResult{ExitReason: reason}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	posquery := gst.NewPositionQuery(gst.FormatTime)
+	if pipeline.Query(posquery) {
+		_, position := posquery.ParsePosition()
+		result.Position = &metav1.Duration{Duration: time.Duration(position)}
+	}
+	durquery := gst.NewDurationQuery(gst.FormatTime)
+	if pipeline.Query(durquery) {
+		if _, duration := durquery.ParseDuration(); duration > 0 {
+			result.Duration = &metav1.Duration{Duration: time.Duration(duration)}
+		}
+	}
+
+	caps := sinkCaps(pipeline)
+	for _, sink := range r.spec.sinks {
+		result.Outputs = append(result.Outputs, &pipelinesmeta.ObjectResult{
+			Name:   sink.Name,
+			Bucket: objectBucket(sink),
+			Caps:   caps[sink.Name],
+		})
+	}
+	if r.spec.src != nil {
+		result.Source = &pipelinesmeta.ObjectResult{
+			Name:   r.spec.src.Name,
+			Bucket: objectBucket(r.spec.src),
+			Media:  r.media,
+		}
+	}
+	r.result = result
+}
+
+// fail marks a finished run as failed with the given error, for when the pipeline runs into trouble
+// after its outcome was recorded.
+func (r *runResult) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.result == nil {
+		return
+	}
+	r.result.ExitReason = pipelinesmeta.ExitReasonError
+	r.result.Error = err.Error()
+}
+
+// write completes the result with the sizes of the objects and writes it as the termination message
+// of the container, and next to the outputs when configured.
+func (r *runResult) write() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.result == nil {
+		return
+	}
+	result := r.result
+	result.ProcessingTime = metav1.Duration{Duration: time.Since(r.start)}
+	if len(r.tags) > 0 {
+		result.Tags = r.tags
+	}
+
+	if result.Source != nil {
+		result.Source.Size = statObjectSize(r.spec.src, util.MinIOSrcCredentialsFromEnv(), pipelinesmeta.MinIOSrcCustomerKeyEnvVar)
+		// Discovery only reports the bitrates of the individual streams
+		if media := result.Source.Media; media != nil && media.Bitrate == 0 && media.Duration != nil && result.Source.Size != nil {
+			if seconds := media.Duration.Seconds(); seconds > 0 {
+				media.Bitrate = uint(float64(*result.Source.Size*8) / seconds)
+			}
+		}
+	}
+	for idx, sink := range r.spec.sinks {
+		result.Outputs[idx].Size = statObjectSize(sink, util.MinIOSinkCredentialsFromEnv(), pipelinesmeta.MinIOSinkCustomerKeyEnvVar)
+	}
+
+	log.Info("Pipeline finished", "Result", result.String())
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		log.Error(err, "Failed to marshal the job result")
+		return
+	}
+
+	if r.spec.cfg.WriteResult && len(r.spec.sinks) > 0 {
+		if err := putSinkObject(r.spec.sinks[0], pipelinesmeta.ResultObjectName(r.spec.sinks[0].Name), out); err != nil {
+			log.Error(err, "Failed to write the job result object")
+		}
+	}
+
+	// Metadata is only written for outputs that were completed
+	if discover := r.spec.cfg.Discover; discover != nil && discover.WriteMetadata && result.ExitReason == pipelinesmeta.ExitReasonFinished &&
+		result.Source != nil && result.Source.Media != nil && len(r.spec.sinks) > 0 {
+		if media, err := json.Marshal(result.Source.Media); err != nil {
+			log.Error(err, "Failed to marshal the discovered metadata")
+		} else if err := putSinkObject(r.spec.sinks[0], pipelinesmeta.MetadataObjectName(r.spec.sinks[0].Name), media); err != nil {
+			log.Error(err, "Failed to write the metadata object")
+		}
+	}
+
+	// There is no container to report to when running locally
+	if r.spec.local {
+		return
+	}
+	if err := writeTerminationMessage(result, out); err != nil {
+		log.Error(err, "Failed to write the job result")
+	}
+}
+
+// sinkCaps returns the caps negotiated on the sink pad of each miniosink and filesink in the pipeline
+// by the key or location it is writing to.
+func sinkCaps(pipeline *gst.Pipeline) map[string]string {
+	caps := make(map[string]string)
+	sinks, err := pipeline.GetSinkElements()
+	if err != nil {
+		return caps
+	}
+	for _, sink := range sinks {
+		var prop string
+		switch sink.GetFactory().GetName() {
+		case "miniosink":
+			prop = "key"
+		case "filesink":
+			prop = "location"
+		default:
+			continue
+		}
+		key, err := sink.GetProperty(prop)
+		if err != nil {
+			continue
+		}
+		pad := sink.GetStaticPad("sink")
+		if pad == nil {
+			continue
+		}
+		if current := pad.GetCurrentCaps(); current != nil {
+			caps[fmt.Sprint(key)] = current.String()
+		}
+	}
+	return caps
+}
+
+// statObjectSize returns the size of the given object, or nil if it could not be found. The SSE-C
+// customer key for the object, if it uses one, is read from customerKeyEnvVar.
+func statObjectSize(obj *pipelinesmeta.Object, creds util.MinIOCredentialsGetter, customerKeyEnvVar string) *int64 {
+	if isLocalObject(obj) {
+		info, err := os.Stat(obj.Name)
+		if err != nil {
+			log.Info("Could not stat file for the job result", "Path", obj.Name, "Error", err.Error())
+			return nil
+		}
+		size := info.Size()
+		return &size
+	}
+	mc, err := util.GetMinIOClient(obj.Config.MinIO, creds)
+	if err != nil {
+		log.Error(err, "Could not create minio client to stat object", "Key", obj.Name)
+		return nil
+	}
+	sse, err := util.GetServerSideEncryption(obj.Config.MinIO, customerKeyEnvVar)
+	if err != nil {
+		log.Error(err, "Could not read the encryption of the object", "Key", obj.Name)
+		return nil
+	}
+	info, err := mc.StatObject(context.Background(), obj.Config.MinIO.GetBucket(), obj.Name, minio.StatObjectOptions{
+		ServerSideEncryption: util.ReadEncryption(sse),
+		VersionID:            obj.VersionID,
+	})
+	if err != nil {
+		log.Info("Could not stat object for the job result", "Key", obj.Name, "Error", err.Error())
+		return nil
+	}
+	return &info.Size
+}
+
+// objectBucket returns the bucket of the given object, or an empty string for local files.
+func objectBucket(obj *pipelinesmeta.Object) string {
+	if isLocalObject(obj) {
+		return ""
+	}
+	return obj.Config.MinIO.GetBucket()
+}
+
+// putSinkObject writes the given JSON document to the given key alongside the sink object, either in
+// its bucket or on the local filesystem.
+func putSinkObject(sink *pipelinesmeta.Object, key string, data []byte) error {
+	if isLocalObject(sink) {
+		return ioutil.WriteFile(key, data, 0644)
+	}
+	cfg := sink.Config.MinIO
+	mc, err := util.GetMinIOClient(cfg, util.MinIOSinkCredentialsFromEnv())
+	if err != nil {
+		return err
+	}
+	sse, err := util.GetServerSideEncryption(cfg, pipelinesmeta.MinIOSinkCustomerKeyEnvVar)
+	if err != nil {
+		return err
+	}
+	_, err = mc.PutObject(context.Background(), cfg.GetBucket(), key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:          "application/json",
+		ServerSideEncryption: sse,
+	})
+	return err
+}
+
+// writeTerminationMessage writes the result where it is picked up as the termination message of
+// the container.
+func writeTerminationMessage(result *pipelinesmeta.JobResult, out []byte) error {
+	out, err := fitTerminationMessage(result, out)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(pipelinesmeta.TerminationMessagePath, out, 0644)
+}
+
+// fitTerminationMessage returns out, the encoded result, if it fits in a termination message.
+// Otherwise tags, then caps, and then the discovered streams are dropped from the result until it
+// does, and the result is encoded again.
+func fitTerminationMessage(result *pipelinesmeta.JobResult, out []byte) ([]byte, error) {
+	var err error
+	if len(out) > maxReportSize {
+		result.Tags = nil
+		if out, err = json.Marshal(result); err != nil {
+			return nil, err
+		}
+	}
+	if len(out) > maxReportSize {
+		for _, output := range result.Outputs {
+			output.Caps = ""
+		}
+		if out, err = json.Marshal(result); err != nil {
+			return nil, err
+		}
+	}
+	if len(out) > maxReportSize && result.Source != nil && result.Source.Media != nil {
+		result.Source.Media.Streams = nil
+		if out, err = json.Marshal(result); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+func TestFitTerminationMessage(t *testing.T) {
+	newResult := func(tagSize, capsSize, streams int) *pipelinesmeta.JobResult {
+		result := &pipelinesmeta.JobResult{
+			ExitReason: pipelinesmeta.ExitReasonFinished,
+			Source: &pipelinesmeta.ObjectResult{
+				Name:  "in.mp4",
+				Media: &pipelinesmeta.MediaInfo{Container: "video/quicktime"},
+			},
+			Outputs: []*pipelinesmeta.ObjectResult{
+				{Name: "out-720.mp4", Caps: "video/quicktime" + strings.Repeat(",a=b", capsSize/4)},
+				{Name: "out-1080.mp4", Caps: "video/quicktime" + strings.Repeat(",a=b", capsSize/4)},
+			},
+			Tags: map[string]string{"title": strings.Repeat("t", tagSize)},
+		}
+		for idx := 0; idx < streams; idx++ {
+			result.Source.Media.Streams = append(result.Source.Media.Streams, &pipelinesmeta.MediaStream{Type: "audio", Codec: "audio/mpeg"})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name            string
+		result          *pipelinesmeta.JobResult
+		expectedTags    bool
+		expectedCaps    bool
+		expectedStreams bool
+	}{
+		{
+			name:            "fits",
+			result:          newResult(100, 100, 2),
+			expectedTags:    true,
+			expectedCaps:    true,
+			expectedStreams: true,
+		},
+		{
+			name:            "tags are dropped first",
+			result:          newResult(maxReportSize, 100, 2),
+			expectedCaps:    true,
+			expectedStreams: true,
+		},
+		{
+			name:            "caps are dropped next",
+			result:          newResult(maxReportSize, maxReportSize, 2),
+			expectedStreams: true,
+		},
+		{
+			name:   "streams are dropped last",
+			result: newResult(maxReportSize, maxReportSize, 200),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := json.Marshal(tt.result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := fitTerminationMessage(tt.result, in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out) > maxReportSize {
+				t.Fatalf("Expected the message to fit in %d bytes, got %d", maxReportSize, len(out))
+			}
+
+			result := &pipelinesmeta.JobResult{}
+			if err := json.Unmarshal(out, result); err != nil {
+				t.Fatal(err)
+			}
+			if hasTags := len(result.Tags) > 0; hasTags != tt.expectedTags {
+				t.Errorf("Expected tags to be kept %v, got %v", tt.expectedTags, hasTags)
+			}
+			if hasCaps := result.Outputs[0].Caps != ""; hasCaps != tt.expectedCaps {
+				t.Errorf("Expected caps to be kept %v, got %v", tt.expectedCaps, hasCaps)
+			}
+			if hasStreams := len(result.Source.Media.Streams) > 0; hasStreams != tt.expectedStreams {
+				t.Errorf("Expected streams to be kept %v, got %v", tt.expectedStreams, hasStreams)
+			}
+			if len(result.Outputs) != 2 || result.Source.Name != "in.mp4" || result.ExitReason != pipelinesmeta.ExitReasonFinished {
+				t.Errorf("Expected the rest of the result to be kept, got %+v", result)
+			}
+		})
+	}
+}
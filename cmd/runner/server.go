@@ -0,0 +1,235 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tinyzimmer/go-gst/gst"
+)
+
+// counterProperties are the read-only properties of the minio elements exposed as metrics.
+var counterProperties = map[string]*prometheus.Desc{
+	"bytes-read": prometheus.NewDesc(
+		"gst_pipeline_src_bytes_read_total",
+		"The number of bytes read from the source object.",
+		[]string{"element", "key"}, nil,
+	),
+	"bytes-fetched": prometheus.NewDesc(
+		"gst_pipeline_src_bytes_fetched_total",
+		"The number of bytes fetched for the source object, including read-ahead discarded on seeks.",
+		[]string{"element", "key"}, nil,
+	),
+	"bytes-uploaded": prometheus.NewDesc(
+		"gst_pipeline_sink_bytes_uploaded_total",
+		"The number of bytes uploaded for the output object.",
+		[]string{"element", "key"}, nil,
+	),
+}
+
+var (
+	positionDesc = prometheus.NewDesc(
+		"gst_pipeline_position_seconds",
+		"The current position of the pipeline.",
+		nil, nil,
+	)
+	durationDesc = prometheus.NewDesc(
+		"gst_pipeline_duration_seconds",
+		"The duration of the pipeline's input, if it is known.",
+		nil, nil,
+	)
+)
+
+// pipelineMetrics exposes the state of a running pipeline to Prometheus. The position and the
+// byte counters of the minio elements are queried when scraped, while QoS and buffering messages
+// are counted as they arrive on the bus.
+type pipelineMetrics struct {
+	pipeline  *gst.Pipeline
+	qosEvents *prometheus.CounterVec
+	buffering *prometheus.GaugeVec
+}
+
+func newPipelineMetrics(pipeline *gst.Pipeline) *pipelineMetrics {
+	return &pipelineMetrics{
+		pipeline: pipeline,
+		qosEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gst_pipeline_qos_events_total",
+			Help: "The number of QoS messages posted by elements, each for a buffer that was dropped or late.",
+		}, []string{"element"}),
+		buffering: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gst_pipeline_buffering_percent",
+			Help: "The last buffering level reported by elements.",
+		}, []string{"element"}),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *pipelineMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- positionDesc
+	ch <- durationDesc
+	for _, desc := range counterProperties {
+		ch <- desc
+	}
+	m.qosEvents.Describe(ch)
+	m.buffering.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *pipelineMetrics) Collect(ch chan<- prometheus.Metric) {
+	posquery := gst.NewPositionQuery(gst.FormatTime)
+	if m.pipeline.Query(posquery) {
+		_, position := posquery.ParsePosition()
+		ch <- prometheus.MustNewConstMetric(positionDesc, prometheus.GaugeValue, time.Duration(position).Seconds())
+	}
+	durquery := gst.NewDurationQuery(gst.FormatTime)
+	if m.pipeline.Query(durquery) {
+		if _, duration := durquery.ParseDuration(); duration > 0 {
+			ch <- prometheus.MustNewConstMetric(durationDesc, prometheus.GaugeValue, time.Duration(duration).Seconds())
+		}
+	}
+
+	elements, err := m.pipeline.GetElementsRecursive()
+	if err != nil {
+		log.Error(err, "Failed to list pipeline elements for metrics")
+	}
+	for _, elem := range elements {
+		for prop, desc := range counterProperties {
+			if _, err := elem.GetPropertyType(prop); err != nil {
+				continue
+			}
+			value, err := elem.GetProperty(prop)
+			if err != nil {
+				continue
+			}
+			count, ok := value.(uint64)
+			if !ok {
+				continue
+			}
+			key, _ := elem.GetProperty("key")
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(count), elem.GetName(), fmt.Sprint(key))
+		}
+	}
+
+	m.qosEvents.Collect(ch)
+	m.buffering.Collect(ch)
+}
+
+// handleMessage records the QoS and buffering messages posted on the bus.
+func (m *pipelineMetrics) handleMessage(msg *gst.Message) {
+	switch msg.Type() {
+	case gst.MessageQoS:
+		m.qosEvents.WithLabelValues(msg.Source()).Inc()
+	case gst.MessageBuffering:
+		m.buffering.WithLabelValues(msg.Source()).Set(float64(msg.ParseBuffering()))
+	}
+}
+
+// graphElement is an element in the JSON view of the pipeline graph.
+type graphElement struct {
+	Name    string      `json:"name"`
+	Factory string      `json:"factory,omitempty"`
+	State   string      `json:"state"`
+	Pads    []*graphPad `json:"pads,omitempty"`
+}
+
+// graphPad is a pad of an element in the JSON view of the pipeline graph.
+type graphPad struct {
+	Name      string `json:"name"`
+	Direction string `json:"direction"`
+	Caps      string `json:"caps,omitempty"`
+	// The peer pad in the form element.pad
+	Peer string `json:"peer,omitempty"`
+}
+
+// pipelineGraph returns the elements of the pipeline, including those inside bins, with their pads
+// and links.
+func pipelineGraph(pipeline *gst.Pipeline) ([]*graphElement, error) {
+	elements, err := pipeline.GetElementsRecursive()
+	if err != nil {
+		return nil, err
+	}
+	graph := make([]*graphElement, 0, len(elements))
+	for _, elem := range elements {
+		node := &graphElement{
+			Name:  elem.GetName(),
+			State: elem.GetState().String(),
+		}
+		if factory := elem.GetFactory(); factory != nil {
+			node.Factory = factory.GetName()
+		}
+		pads, err := elem.GetPads()
+		if err != nil {
+			return nil, err
+		}
+		for _, pad := range pads {
+			gpad := &graphPad{
+				Name:      pad.GetName(),
+				Direction: pad.GetDirection().String(),
+			}
+			if caps := pad.GetCurrentCaps(); caps != nil {
+				gpad.Caps = caps.String()
+			}
+			if peer := pad.GetPeer(); peer != nil {
+				if parent := peer.GetParentElement(); parent != nil {
+					gpad.Peer = fmt.Sprintf("%s.%s", parent.GetName(), peer.GetName())
+				}
+			}
+			node.Pads = append(node.Pads, gpad)
+		}
+		graph = append(graph, node)
+	}
+	return graph, nil
+}
+
+// startMetricsServer serves the metrics and the pipeline graph on the given port in the background.
+func startMetricsServer(pipeline *gst.Pipeline, metrics *pipelineMetrics, port int32) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/pipeline", func(w http.ResponseWriter, r *http.Request) {
+		graph, err := pipelineGraph(pipeline)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(graph); err != nil {
+			log.Error(err, "Failed to write the pipeline graph")
+		}
+	})
+	mux.HandleFunc("/pipeline.dot", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		if _, err := w.Write([]byte(pipeline.DebugBinToDotData(gst.DebugGraphShowAll))); err != nil {
+			log.Error(err, "Failed to write the pipeline dot graph")
+		}
+	})
+
+	addr := fmt.Sprintf(":%d", port)
+	log.Info("Serving pipeline metrics", "Address", addr)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Error(err, "Metrics server exited")
+		}
+	}()
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// terminationAbortMargin is the time left of the grace period for aborting the pipeline when
+// finalizing its outputs takes too long.
+const terminationAbortMargin = 5 * time.Second
+
+// handleTermination stops the pipeline according to its termination policy when the process receives
+// a SIGTERM or SIGINT. When finalizing, the regular EOS handling on the bus ends the process. Aborting
+// brings the pipeline to NULL, at which point sinks remove any partially uploaded outputs, and exits.
+// Either way the run is reported as terminated in the result.
+func handleTermination(pipeline *gst.Pipeline, cfg *pipelinesmeta.PipelineConfig, result *runResult, wd *watchdog) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		sig := <-sigs
+		policy := cfg.GetTerminationPolicy()
+		log.Info("Received signal, terminating pipeline", "Signal", sig.String(), "Policy", policy)
+		result.markTerminated()
+		// Draining the pipeline is bounded by the grace period rather than the stall timeout
+		wd.disarm()
+
+		if policy == pipelinesmeta.TerminationPolicyFinalize {
+			timeout := cfg.GetTerminationGracePeriod() - terminationAbortMargin
+			if timeout <= 0 {
+				timeout = cfg.GetTerminationGracePeriod() / 2
+			}
+			log.Info("Sending EOS to finalize outputs", "Timeout", timeout)
+			pipeline.SendEvent(gst.NewEOSEvent())
+			select {
+			case <-time.After(timeout):
+				log.Info("Outputs were not finalized in time, aborting pipeline")
+			case sig := <-sigs:
+				log.Info("Received second signal, aborting pipeline", "Signal", sig.String())
+			}
+		}
+
+		result.finish(pipeline, pipelinesmeta.ExitReasonTerminated, nil)
+		if err := wd.stop(); err != nil {
+			log.Error(err, "Pipeline failed to shut down")
+		} else {
+			log.Info("Pipeline aborted")
+		}
+		result.write()
+		os.Exit(6)
+	}()
+}
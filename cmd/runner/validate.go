@@ -0,0 +1,168 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// validationTimeout is how long a pipeline is given to preroll during validation.
+const validationTimeout = time.Minute
+
+// maxReportSize is the maximum size of a termination message read by the kubelet.
+const maxReportSize = 4096
+
+// runValidation validates the pipeline in spec, writes the report for the controller, and returns
+// the exit code for the process.
+func runValidation(spec *pipelineSpec) int {
+	report := validatePipeline(spec)
+	log.Info("Pipeline validation finished", "Valid", report.Valid, "Report", report.String())
+	// There is no container to report to when running locally
+	if !spec.local {
+		if err := writeValidationReport(report); err != nil {
+			log.Error(err, "Failed to write the validation report")
+		}
+	}
+	if !report.Valid {
+		return 5
+	}
+	return 0
+}
+
+// validatePipeline checks the configured elements against the registry and then builds the pipeline,
+// with its outputs discarded, and brings it to PAUSED. Without a source object to read it is only
+// brought to READY, since sources only open their input on the way to PAUSED, and the report is
+// marked partial.
+func validatePipeline(spec *pipelineSpec) *pipelinesmeta.ValidationReport {
+	report := &pipelinesmeta.ValidationReport{Valid: true, State: gst.StateNull.String()}
+
+	validateElements(spec.cfg, report)
+	if !report.Valid {
+		return report
+	}
+
+	pipeline, err := buildPipelineFromCR(spec)
+	if err != nil {
+		report.AddIssue("", "", err.Error())
+		return report
+	}
+	defer pipeline.BlockSetState(gst.StateNull)
+
+	target := gst.StatePaused
+	if spec.src == nil || spec.src.Name == "" || spec.live != nil {
+		target = gst.StateReady
+	}
+
+	bus := pipeline.GetPipelineBus()
+	if err := pipeline.SetState(target); err != nil {
+		// The reason for the failure is posted on the bus
+		collectErrors(bus, report)
+		if report.Valid {
+			report.AddIssue("", "", err.Error())
+		}
+		return report
+	}
+
+	deadline := time.Now().Add(validationTimeout)
+	for pipeline.GetState() != target {
+		if time.Now().After(deadline) {
+			report.AddIssue("", "", fmt.Sprintf("Timed out after %s waiting for the pipeline to reach %s", validationTimeout, target.String()))
+			return report
+		}
+		if msg := bus.TimedPopFiltered(100*time.Millisecond, gst.MessageError); msg != nil {
+			addErrorIssue(report, msg)
+			collectErrors(bus, report)
+			return report
+		}
+	}
+
+	collectErrors(bus, report)
+	if report.Valid {
+		report.State = target.String()
+		report.Partial = target != gst.StatePaused
+	}
+	return report
+}
+
+// validateElements checks that the configured elements are registered, that their properties exist,
+// and that the values given for them can be deserialized.
+func validateElements(cfg *pipelinesmeta.PipelineConfig, report *pipelinesmeta.ValidationReport) {
+	for _, elemCfg := range cfg.Elements {
+		// gotos and linktos do not create elements
+		if elemCfg.Name == "" {
+			continue
+		}
+		if gst.Find(elemCfg.Name) == nil {
+			report.AddIssue(elemCfg.Name, "", "No element factory is registered with this name")
+			continue
+		}
+		elem, err := gst.NewElement(elemCfg.Name)
+		if err != nil {
+			report.AddIssue(elemCfg.Name, "", err.Error())
+			continue
+		}
+		for propName, propValue := range elemCfg.Properties {
+			propType, err := elem.GetPropertyType(propName)
+			if err != nil {
+				report.AddIssue(elemCfg.Name, propName, "The element has no property with this name")
+				continue
+			}
+			val, err := propValue.GetValue()
+			if err == nil {
+				_, err = goStringToGValueByType(propType, serializePropertyValue(propType, val))
+			}
+			if err != nil {
+				report.AddIssue(elemCfg.Name, propName, err.Error())
+			}
+		}
+	}
+}
+
+// collectErrors adds any errors already posted on the bus to the report.
+func collectErrors(bus *gst.Bus, report *pipelinesmeta.ValidationReport) {
+	for msg := bus.TimedPopFiltered(0, gst.MessageError); msg != nil; msg = bus.TimedPopFiltered(0, gst.MessageError) {
+		addErrorIssue(report, msg)
+	}
+}
+
+func addErrorIssue(report *pipelinesmeta.ValidationReport, msg *gst.Message) {
+	gerr := msg.ParseError()
+	report.AddIssue(msg.Source(), "", gerr.Error())
+}
+
+// writeValidationReport writes the report where it is picked up as the termination message of the
+// container. Issues are dropped from the end of the report if it would not fit.
+func writeValidationReport(report *pipelinesmeta.ValidationReport) error {
+	out, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	for len(out) > maxReportSize && len(report.Issues) > 1 {
+		report.Issues = report.Issues[:len(report.Issues)-1]
+		if out, err = json.Marshal(report); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(pipelinesmeta.TerminationMessagePath, out, 0644)
+}
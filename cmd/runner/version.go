@@ -0,0 +1,69 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	"github.com/tinyzimmer/gst-pipeline-operator/pkg/util"
+)
+
+// verifySourceVersion makes sure the source object can still be read as it was when the job was
+// created. On versioned buckets the version must still exist, and otherwise the ETag must match, so
+// that an object deleted or overwritten since fails the job with a clear error. miniosrc makes the
+// same checks on its reads, in case the object changes after this one.
+func verifySourceVersion(obj *pipelinesmeta.Object) error {
+	if isLocalObject(obj) || (obj.VersionID == "" && obj.ETag == "") {
+		return nil
+	}
+	cfg := obj.Config.MinIO
+	mc, err := util.GetMinIOClient(cfg, util.MinIOSrcCredentialsFromEnv())
+	if err != nil {
+		return err
+	}
+	sse, err := util.GetServerSideEncryption(cfg, pipelinesmeta.MinIOSrcCustomerKeyEnvVar)
+	if err != nil {
+		return err
+	}
+	opts := minio.StatObjectOptions{
+		ServerSideEncryption: util.ReadEncryption(sse),
+		VersionID:            obj.VersionID,
+	}
+	if etag := strings.Trim(obj.ETag, `"`); etag != "" {
+		if err := opts.SetMatchETag(etag); err != nil {
+			return err
+		}
+	}
+	if _, err := mc.StatObject(context.Background(), cfg.GetBucket(), obj.Name, opts); err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchKey", "NoSuchVersion":
+			if obj.VersionID != "" {
+				return fmt.Errorf("Version %s of the source object %s has been deleted", obj.VersionID, obj.Name)
+			}
+			return fmt.Errorf("The source object %s has been deleted", obj.Name)
+		case "PreconditionFailed":
+			return fmt.Errorf("The source object %s has been overwritten since the job was created", obj.Name)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinyzimmer/go-gst/gst"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+// maxActiveElements is the number of elements listed when reporting a stalled pipeline.
+const maxActiveElements = 5
+
+// watchdog fails runs that stop making progress. While watching, it probes the src pads of every
+// element in the pipeline, including pads added later, to record when each element last passed a
+// buffer downstream. The pipeline makes progress while its position advances or any element passes
+// a buffer, so pipelines without a TIME position are watched as well.
+type watchdog struct {
+	pipeline        *gst.Pipeline
+	stallTimeout    time.Duration
+	shutdownTimeout time.Duration
+
+	mu sync.Mutex
+	// the unix nanoseconds each element last pushed a buffer, by element name
+	activity map[string]*int64
+	// the pads already probed, by element.pad
+	probed map[string]bool
+	// set once the pipeline is shutting down, after which stalls are no longer reported
+	disarmed bool
+	done     chan struct{}
+}
+
+func newWatchdog(pipeline *gst.Pipeline, cfg *pipelinesmeta.PipelineConfig) *watchdog {
+	return &watchdog{
+		pipeline:        pipeline,
+		stallTimeout:    cfg.GetStallTimeout(),
+		shutdownTimeout: cfg.GetShutdownTimeout(),
+		activity:        make(map[string]*int64),
+		probed:          make(map[string]bool),
+		done:            make(chan struct{}),
+	}
+}
+
+// watch checks the position of the pipeline in the background and calls onStall if it has not
+// advanced within the stall timeout. It does nothing if stall detection is disabled.
+func (w *watchdog) watch(onStall func(error)) {
+	if w.stallTimeout <= 0 {
+		return
+	}
+	interval := w.stallTimeout / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	log.Info("Watching the pipeline for stalls", "Timeout", w.stallTimeout)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastPosition int64 = -1
+		lastProgress := time.Now()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+			}
+			w.probePads()
+
+			posquery := gst.NewPositionQuery(gst.FormatTime)
+			if w.pipeline.Query(posquery) {
+				if _, position := posquery.ParsePosition(); position != lastPosition {
+					lastPosition, lastProgress = position, time.Now()
+					continue
+				}
+			}
+			if last := w.latestActivity(); last.After(lastProgress) {
+				lastProgress = last
+			}
+			if time.Since(lastProgress) < w.stallTimeout {
+				continue
+			}
+			position := "an unknown position"
+			if lastPosition >= 0 {
+				position = time.Duration(lastPosition).String()
+			}
+			err := fmt.Errorf("The pipeline has not advanced from %s in %s, last active elements: %s", position, w.stallTimeout, w.lastActive())
+			if w.disarm() {
+				onStall(err)
+			}
+			return
+		}
+	}()
+}
+
+// disarm stops watching for stalls, for when the pipeline starts shutting down. It returns false if
+// the watchdog was already disarmed.
+func (w *watchdog) disarm() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.disarmed {
+		return false
+	}
+	w.disarmed = true
+	close(w.done)
+	return true
+}
+
+// stop brings the pipeline to NULL, and returns an error if it does not get there within the shutdown
+// timeout. Stalls are no longer reported once it is called.
+func (w *watchdog) stop() error {
+	w.disarm()
+	done := make(chan struct{})
+	go func() {
+		w.pipeline.BlockSetState(gst.StateNull)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(w.shutdownTimeout):
+		return fmt.Errorf("The pipeline did not reach NULL within %s, last active elements: %s", w.shutdownTimeout, w.lastActive())
+	}
+}
+
+// probePads adds a probe to any src pad in the pipeline that does not have one yet.
+func (w *watchdog) probePads() {
+	elements, err := w.pipeline.GetElementsRecursive()
+	if err != nil {
+		log.Error(err, "Failed to list pipeline elements for the watchdog")
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, elem := range elements {
+		pads, err := elem.GetSrcPads()
+		if err != nil {
+			continue
+		}
+		name := elem.GetName()
+		for _, pad := range pads {
+			padName := fmt.Sprintf("%s.%s", name, pad.GetName())
+			if w.probed[padName] {
+				continue
+			}
+			w.probed[padName] = true
+			last, ok := w.activity[name]
+			if !ok {
+				last = new(int64)
+				w.activity[name] = last
+			}
+			pad.AddProbe(gst.PadProbeTypeBuffer, func(*gst.Pad, *gst.PadProbeInfo) gst.PadProbeReturn {
+				atomic.StoreInt64(last, time.Now().UnixNano())
+				return gst.PadProbeOK
+			})
+		}
+	}
+}
+
+// latestActivity returns the last time any element passed a buffer downstream.
+func (w *watchdog) latestActivity() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var latest int64
+	for _, last := range w.activity {
+		if ts := atomic.LoadInt64(last); ts > latest {
+			latest = ts
+		}
+	}
+	return time.Unix(0, latest)
+}
+
+// lastActive describes the elements that most recently passed a buffer downstream, along with how
+// long ago they did.
+func (w *watchdog) lastActive() string {
+	type elementActivity struct {
+		name string
+		last int64
+	}
+	w.mu.Lock()
+	active := make([]elementActivity, 0, len(w.activity))
+	for name, last := range w.activity {
+		if ts := atomic.LoadInt64(last); ts > 0 {
+			active = append(active, elementActivity{name: name, last: ts})
+		}
+	}
+	w.mu.Unlock()
+	if len(active) == 0 {
+		return "none"
+	}
+
+	sort.Slice(active, func(i, j int) bool { return active[i].last > active[j].last })
+	if len(active) > maxActiveElements {
+		active = active[:maxActiveElements]
+	}
+	descs := make([]string, len(active))
+	for idx, elem := range active {
+		ago := time.Since(time.Unix(0, elem.last)).Round(time.Millisecond)
+		descs[idx] = fmt.Sprintf("%s (%s ago)", elem.name, ago)
+	}
+	return strings.Join(descs, ", ")
+}
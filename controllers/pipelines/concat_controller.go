@@ -0,0 +1,150 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+	"github.com/tinyzimmer/gst-pipeline-operator/pkg/managers"
+)
+
+// ConcatReconciler reconciles a Concat object
+type ConcatReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=concats,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=concats/status,verbs=get;update;patch
+
+// Reconcile reconciles a concat pipeline.
+func (r *ConcatReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("concat", req.NamespacedName)
+
+	// Fetch the object for the request
+	pipeline := &pipelinesv1.Concat{}
+	err := r.Client.Get(ctx, req.NamespacedName, pipeline)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			// Object was deleted
+			return ctrl.Result{}, nil
+		}
+		// Requeue any other error
+		return ctrl.Result{}, err
+	}
+
+	// Get the controller for this pipeline
+	controller := managers.GetManagerForPipeline(r.Client, pipeline)
+
+	// Check if we are running finalizers
+	if pipeline.GetDeletionTimestamp() != nil {
+		if controller.IsRunning() {
+			controller.Stop()
+		}
+		return ctrl.Result{}, r.removeFinalizers(ctx, reqLogger, pipeline)
+	}
+
+	// Report an invalid manifest pattern instead of watching for manifests that never match
+	if _, err := pipeline.GetManifestRegex(); err != nil {
+		reqLogger.Info("Manifest pattern is invalid", "Pattern", pipeline.Spec.ManifestPattern, "Error", err.Error())
+		if controller.IsRunning() {
+			reqLogger.Info("Stopping PipelineManager")
+			controller.Stop()
+		}
+		return ctrl.Result{}, r.setInSyncCondition(ctx, pipeline, metav1.ConditionFalse, "InvalidManifestPattern",
+			fmt.Sprintf("The manifest pattern %q is invalid: %s", pipeline.Spec.ManifestPattern, err.Error()))
+	}
+
+	if !controller.IsRunning() {
+		reqLogger.Info("Starting PipelineManager")
+		if err := controller.Start(pipeline); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else {
+		reqLogger.Info("PipelineManager is already running, reloading config")
+		controller.Reload(pipeline)
+	}
+
+	if err := r.ensureFinalizers(ctx, reqLogger, pipeline); err != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.setInSyncCondition(ctx, pipeline, metav1.ConditionTrue, string(pipelinesmeta.PipelineInSync), "The pipeline configuration is in-sync"); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	reqLogger.Info("Reconcile finished")
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager adds the ConcatReconciler to the given manager.
+func (r *ConcatReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&pipelinesv1.Concat{}).
+		Complete(r)
+}
+
+func (r *ConcatReconciler) generationObserved(pipeline *pipelinesv1.Concat) bool {
+	for _, cond := range pipeline.Status.Conditions {
+		if cond.ObservedGeneration == pipeline.GetGeneration() {
+			return true
+		}
+	}
+	return false
+}
+
+// setInSyncCondition records the state of the current generation in an InSync condition, unless it
+// has been recorded already.
+func (r *ConcatReconciler) setInSyncCondition(ctx context.Context, pipeline *pipelinesv1.Concat, status metav1.ConditionStatus, reason, message string) error {
+	if r.generationObserved(pipeline) {
+		return nil
+	}
+	pipeline.Status.Conditions = append(pipeline.Status.Conditions, metav1.Condition{
+		Type:               string(pipelinesmeta.PipelineInSync),
+		Status:             status,
+		ObservedGeneration: pipeline.GetGeneration(),
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	return r.Client.Status().Update(ctx, pipeline)
+}
+
+func (r *ConcatReconciler) removeFinalizers(ctx context.Context, reqLogger logr.Logger, pipeline *pipelinesv1.Concat) error {
+	pipeline.SetFinalizers([]string{})
+	return r.Client.Update(ctx, pipeline)
+}
+
+func (r *ConcatReconciler) ensureFinalizers(ctx context.Context, reqLogger logr.Logger, pipeline *pipelinesv1.Concat) error {
+	finalizers := pipeline.GetFinalizers()
+	if !contains(finalizers, pipelineFinalizer) {
+		reqLogger.Info("Setting finalizers on pipeline")
+		pipeline.SetFinalizers([]string{pipelineFinalizer})
+		return r.Client.Update(ctx, pipeline)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"encoding/json"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var liveReplicas int32 = 1
+
+func newLiveDeployment(ingest *pipelinesv1.LiveIngest) (*appsv1.Deployment, error) {
+	sinkConfig := ingest.GetSinkConfig().MinIO
+	sinkSecret, err := sinkConfig.GetCredentialsSecret()
+	if err != nil {
+		return nil, err
+	}
+	sinkKeyEnv, err := customerKeyEnv(sinkConfig, pipelinesmeta.MinIOSinkCustomerKeyEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	pipelineCfg := ingest.GetPipelineConfig()
+	marshaledConfig, err := json.Marshal(pipelineCfg)
+	if err != nil {
+		return nil, err
+	}
+	marshaledSinks, err := json.Marshal(ingest.GetSinkObjects())
+	if err != nil {
+		return nil, err
+	}
+	marshaledSrc, err := json.Marshal(ingest.Spec.Src)
+	if err != nil {
+		return nil, err
+	}
+	marshaledSegments, err := json.Marshal(ingest.Spec.Segments)
+	if err != nil {
+		return nil, err
+	}
+	debugEnv, err := debugDestinationEnv(pipelineCfg)
+	if err != nil {
+		return nil, err
+	}
+	labels := ingest.GetDeploymentLabels()
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ingest.GetName(),
+			Namespace:       ingest.GetNamespace(),
+			Labels:          labels,
+			OwnerReferences: ingest.OwnerReferences(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &liveReplicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			// Never run two recorders for the same stream
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: pipelineCfg.GetPodAnnotations(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:                 corev1.RestartPolicyAlways,
+					TerminationGracePeriodSeconds: pipelineCfg.GetTerminationGracePeriodSeconds(),
+					Containers: []corev1.Container{
+						{
+							Name:      "gstreamer",
+							Image:     pipelineCfg.GetImage(),
+							Resources: pipelineCfg.Resources,
+							Ports:     pipelineCfg.GetContainerPorts(),
+							Env: []corev1.EnvVar{
+								{
+									Name:  "GST_DEBUG",
+									Value: pipelineCfg.GetGSTDebug(),
+								},
+								{
+									Name:  pipelinesmeta.JobLiveSourceEnvVar,
+									Value: string(marshaledSrc),
+								},
+								{
+									Name:  pipelinesmeta.JobSegmentsEnvVar,
+									Value: string(marshaledSegments),
+								},
+								{
+									Name:  pipelinesmeta.JobSinkObjectsEnvVar,
+									Value: string(marshaledSinks),
+								},
+								{
+									Name:  pipelinesmeta.JobPipelineConfigEnvVar,
+									Value: string(marshaledConfig),
+								},
+								{
+									Name: pipelinesmeta.MinIOSinkAccessKeyIDEnvVar,
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{
+												Name: sinkSecret,
+											},
+											Key: pipelinesmeta.AccessKeyIDKey,
+										},
+									},
+								},
+								{
+									Name: pipelinesmeta.MinIOSinkSecretAccessKeyEnvVar,
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{
+												Name: sinkSecret,
+											},
+											Key: pipelinesmeta.SecretAccessKeyKey,
+										},
+									},
+								},
+							},
+							// The runner creates this file once data from the stream is being recorded
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"test", "-f", pipelinesmeta.LiveConnectedFile},
+									},
+								},
+								PeriodSeconds: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	container.Env = append(container.Env, sinkKeyEnv...)
+	container.Env = append(container.Env, debugEnv...)
+
+	return deployment, nil
+}
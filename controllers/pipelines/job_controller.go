@@ -39,6 +39,7 @@ type JobReconciler struct {
 
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups=pipelines.gst.io,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=pipelines.gst.io,resources=jobs/status,verbs=get;update;patch
 
@@ -62,6 +63,8 @@ func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		pipeline, err = job.GetTransformPipeline(ctx, r.Client)
 	case pipelinesv1.PipelineSplitTransform:
 		pipeline, err = job.GetSplitTransformPipeline(ctx, r.Client)
+	case pipelinesv1.PipelineConcat:
+		pipeline, err = job.GetConcatPipeline(ctx, r.Client)
 	default:
 		err = fmt.Errorf("Unknown pipeline kind: %s", string(job.GetPipelineKind()))
 	}
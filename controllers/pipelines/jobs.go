@@ -19,6 +19,7 @@ package pipelines
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
 	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
@@ -113,6 +114,9 @@ func reconcileJob(ctx context.Context, reqLogger logr.Logger, c client.Client, p
 		// Add job finished status condition
 		if !statusObservedForGeneration(string(pipelinesv1.JobFinished), "JobFinished", pipelineJob) {
 			reqLogger.Info("Job finished successfully, updating status")
+			if err := setJobResult(ctx, reqLogger, c, pipelineJob, found); err != nil {
+				return err
+			}
 			pipelineJob.Status.Conditions = append(pipelineJob.Status.Conditions, metav1.Condition{
 				Type:               string(pipelinesv1.JobFinished),
 				Status:             metav1.ConditionTrue,
@@ -131,6 +135,9 @@ func reconcileJob(ctx context.Context, reqLogger logr.Logger, c client.Client, p
 		// Add job failed status condition
 		if !statusObservedForGeneration(string(pipelinesv1.JobFailed), "JobFailed", pipelineJob) {
 			reqLogger.Info("Job failed, updating status")
+			if err := setJobResult(ctx, reqLogger, c, pipelineJob, found); err != nil {
+				return err
+			}
 			pipelineJob.Status.Conditions = append(pipelineJob.Status.Conditions, metav1.Condition{
 				Type:               string(pipelinesv1.JobFailed),
 				Status:             metav1.ConditionTrue,
@@ -147,6 +154,121 @@ func reconcileJob(ctx context.Context, reqLogger logr.Logger, c client.Client, p
 	return nil
 }
 
+// setJobResult copies the result reported by the runner into the status of the pipeline job. A result
+// that cannot be read is logged and left out, since it should not keep the job status from being updated.
+func setJobResult(ctx context.Context, reqLogger logr.Logger, c client.Client, pipelineJob *pipelinesv1.Job, job *batchv1.Job) error {
+	msg, err := getTerminationMessage(ctx, c, job)
+	if err != nil {
+		return err
+	}
+	if msg == "" {
+		reqLogger.Info("The pipeline did not report a result")
+		return nil
+	}
+	result := &pipelinesmeta.JobResult{}
+	if err := json.Unmarshal([]byte(msg), result); err != nil {
+		reqLogger.Info("Could not parse the result reported by the pipeline", "Error", err.Error())
+		return nil
+	}
+	pipelineJob.Status.Result = result
+	return nil
+}
+
+// getTerminationMessage returns the termination message left by the last container to exit in the
+// pods of the given job. An empty string is returned if there is none.
+func getTerminationMessage(ctx context.Context, c client.Client, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(job.GetNamespace()), client.MatchingLabels{"job-name": job.GetName()}); err != nil {
+		return "", err
+	}
+	var latest *corev1.ContainerStateTerminated
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			// Containers restarted after a failure keep the message of their last run
+			for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+				if terminated == nil || terminated.Message == "" {
+					continue
+				}
+				if latest == nil || latest.FinishedAt.Before(&terminated.FinishedAt) {
+					latest = terminated
+				}
+			}
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	return latest.Message, nil
+}
+
+// debugDestinationEnv returns the environment variables holding the credentials for the debug
+// destination of the pipeline, if it has one.
+func debugDestinationEnv(pipelineCfg *pipelinesmeta.PipelineConfig) ([]corev1.EnvVar, error) {
+	dest := pipelineCfg.GetDebugDestination()
+	if dest == nil {
+		return nil, nil
+	}
+	debugSecret, err := dest.MinIO.GetCredentialsSecret()
+	if err != nil {
+		return nil, err
+	}
+	env := []corev1.EnvVar{
+		{
+			Name: pipelinesmeta.MinIODebugAccessKeyIDEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: debugSecret,
+					},
+					Key: pipelinesmeta.AccessKeyIDKey,
+				},
+			},
+		},
+		{
+			Name: pipelinesmeta.MinIODebugSecretAccessKeyEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: debugSecret,
+					},
+					Key: pipelinesmeta.SecretAccessKeyKey,
+				},
+			},
+		},
+	}
+	keyEnv, err := customerKeyEnv(dest.MinIO, pipelinesmeta.MinIODebugCustomerKeyEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	return append(env, keyEnv...), nil
+}
+
+// customerKeyEnv returns the environment variable holding the SSE-C customer key for the given
+// config, if it encrypts objects with one. The key is referenced from its secret so it never
+// appears in the pod spec.
+func customerKeyEnv(cfg *pipelinesmeta.MinIOConfig, envVar string) ([]corev1.EnvVar, error) {
+	if !cfg.Encryption.IsCustomerKey() {
+		return nil, nil
+	}
+	keySecret, err := cfg.Encryption.GetCustomerKeySecret()
+	if err != nil {
+		return nil, err
+	}
+	return []corev1.EnvVar{
+		{
+			Name: envVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: keySecret,
+					},
+					Key: pipelinesmeta.CustomerKeyKey,
+				},
+			},
+		},
+	}, nil
+}
+
 func jobSucceeded(job *batchv1.Job) bool  { return job.Status.Succeeded == 1 }
 func jobFailed(job *batchv1.Job) bool     { return job.Status.Failed == 1 }
 func jobInProgress(job *batchv1.Job) bool { return job.Status.Succeeded == 0 && job.Status.Failed == 0 }
@@ -154,7 +276,7 @@ func jobPending(job *batchv1.Job) bool    { return job.Status.Active == 0 && job
 
 func newPipelineJob(pipelineJob *pipelinesv1.Job, pipeline pipelinetypes.Pipeline) (*batchv1.Job, error) {
 	// TODO
-	srcConfig := pipeline.GetSrcConfig().MinIO
+	srcConfig := pipelineJob.Spec.Source.Config.MinIO
 	sinkConfig := pipeline.GetSinkConfig().MinIO
 	srcSecret, err := srcConfig.GetCredentialsSecret()
 	if err != nil {
@@ -165,6 +287,9 @@ func newPipelineJob(pipelineJob *pipelinesv1.Job, pipeline pipelinetypes.Pipelin
 		return nil, err
 	}
 	pipelineCfg := pipeline.GetPipelineConfig()
+	if pipelineCfg == nil {
+		return nil, errors.New("The pipeline configuration has not been rendered from its template yet")
+	}
 	marshaledConfig, err := json.Marshal(pipelineCfg)
 	if err != nil {
 		return nil, err
@@ -177,6 +302,93 @@ func newPipelineJob(pipelineJob *pipelinesv1.Job, pipeline pipelinetypes.Pipelin
 	if err != nil {
 		return nil, err
 	}
+	env := []corev1.EnvVar{
+		{
+			Name:  "GST_DEBUG",
+			Value: pipelineCfg.GetGSTDebug(),
+		},
+		{
+			Name:  pipelinesmeta.JobSrcObjectsEnvVar,
+			Value: string(marshaledSrc),
+		},
+		{
+			Name:  pipelinesmeta.JobSinkObjectsEnvVar,
+			Value: string(marshaledSinks),
+		},
+		{
+			Name:  pipelinesmeta.JobPipelineConfigEnvVar,
+			Value: string(marshaledConfig),
+		},
+		{
+			Name: pipelinesmeta.MinIOSrcAccessKeyIDEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: srcSecret,
+					},
+					Key: pipelinesmeta.AccessKeyIDKey,
+				},
+			},
+		},
+		{
+			Name: pipelinesmeta.MinIOSrcSecretAccessKeyEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: srcSecret,
+					},
+					Key: pipelinesmeta.SecretAccessKeyKey,
+				},
+			},
+		},
+		{
+			Name: pipelinesmeta.MinIOSinkAccessKeyIDEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: sinkSecret,
+					},
+					Key: pipelinesmeta.AccessKeyIDKey,
+				},
+			},
+		},
+		{
+			Name: pipelinesmeta.MinIOSinkSecretAccessKeyEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: sinkSecret,
+					},
+					Key: pipelinesmeta.SecretAccessKeyKey,
+				},
+			},
+		},
+	}
+	debugEnv, err := debugDestinationEnv(pipelineCfg)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, debugEnv...)
+	srcKeyEnv, err := customerKeyEnv(srcConfig, pipelinesmeta.MinIOSrcCustomerKeyEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, srcKeyEnv...)
+	sinkKeyEnv, err := customerKeyEnv(sinkConfig, pipelinesmeta.MinIOSinkCustomerKeyEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, sinkKeyEnv...)
+	if len(pipelineJob.Spec.Inputs) > 0 {
+		marshaledInputs, err := json.Marshal(pipelineJob.Spec.Inputs)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  pipelinesmeta.JobInputObjectsEnvVar,
+			Value: string(marshaledInputs),
+		})
+	}
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            pipelineJob.GetName(),
@@ -187,75 +399,19 @@ func newPipelineJob(pipelineJob *pipelinesv1.Job, pipeline pipelinetypes.Pipelin
 		Spec: batchv1.JobSpec{
 			BackoffLimit: &backoffLimit,
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: pipelineCfg.GetPodAnnotations(),
+				},
 				Spec: corev1.PodSpec{
-					RestartPolicy: corev1.RestartPolicyOnFailure,
+					RestartPolicy:                 corev1.RestartPolicyOnFailure,
+					TerminationGracePeriodSeconds: pipelineCfg.GetTerminationGracePeriodSeconds(),
 					Containers: []corev1.Container{
 						{
 							Name:      "gstreamer",
 							Image:     pipelineCfg.GetImage(),
 							Resources: pipelineCfg.Resources,
-							Env: []corev1.EnvVar{
-								{
-									Name:  "GST_DEBUG",
-									Value: pipelineCfg.GetGSTDebug(),
-								},
-								{
-									Name:  pipelinesmeta.JobSrcObjectsEnvVar,
-									Value: string(marshaledSrc),
-								},
-								{
-									Name:  pipelinesmeta.JobSinkObjectsEnvVar,
-									Value: string(marshaledSinks),
-								},
-								{
-									Name:  pipelinesmeta.JobPipelineConfigEnvVar,
-									Value: string(marshaledConfig),
-								},
-								{
-									Name: pipelinesmeta.MinIOSrcAccessKeyIDEnvVar,
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: srcSecret,
-											},
-											Key: pipelinesmeta.AccessKeyIDKey,
-										},
-									},
-								},
-								{
-									Name: pipelinesmeta.MinIOSrcSecretAccessKeyEnvVar,
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: srcSecret,
-											},
-											Key: pipelinesmeta.SecretAccessKeyKey,
-										},
-									},
-								},
-								{
-									Name: pipelinesmeta.MinIOSinkAccessKeyIDEnvVar,
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: sinkSecret,
-											},
-											Key: pipelinesmeta.AccessKeyIDKey,
-										},
-									},
-								},
-								{
-									Name: pipelinesmeta.MinIOSinkSecretAccessKeyEnvVar,
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: sinkSecret,
-											},
-											Key: pipelinesmeta.SecretAccessKeyKey,
-										},
-									},
-								},
-							},
+							Ports:     pipelineCfg.GetContainerPorts(),
+							Env:       env,
 						},
 					},
 				},
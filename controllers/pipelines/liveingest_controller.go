@@ -0,0 +1,160 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+)
+
+// LiveIngestReconciler reconciles a LiveIngest object
+type LiveIngestReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=liveingests,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=liveingests/status,verbs=get;update;patch
+
+// Reconcile reconciles a LiveIngest pipeline
+func (r *LiveIngestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("liveingest", req.NamespacedName)
+
+	// Fetch the object for the request
+	ingest := &pipelinesv1.LiveIngest{}
+	err := r.Client.Get(ctx, req.NamespacedName, ingest)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			// Object was deleted
+			return ctrl.Result{}, nil
+		}
+		// Requeue any other error
+		return ctrl.Result{}, err
+	}
+
+	// The deployment is removed with its owner
+	if ingest.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	deployment, err := newLiveDeployment(ingest)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	found := &appsv1.Deployment{}
+	nn := types.NamespacedName{Name: deployment.GetName(), Namespace: deployment.GetNamespace()}
+	if err := r.Client.Get(ctx, nn, found); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		reqLogger.Info("Creating new Deployment", "Name", deployment.GetName(), "Namespace", deployment.GetNamespace())
+		if err := r.Client.Create(ctx, deployment); err != nil {
+			return ctrl.Result{}, err
+		}
+		found = deployment
+	} else if !equality.Semantic.DeepDerivative(deployment.Spec.Template, found.Spec.Template) {
+		reqLogger.Info("Updating Deployment with new pipeline configuration", "Name", found.GetName(), "Namespace", found.GetNamespace())
+		found.Spec.Template = deployment.Spec.Template
+		if err := r.Client.Update(ctx, found); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := ingest.Status.DeepCopy()
+	status.Restarts, err = r.countRestarts(ctx, ingest)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	switch {
+	case found.Status.ReadyReplicas > 0:
+		status.ConnectionState = pipelinesv1.LiveConnected
+	case status.Restarts > 0:
+		status.ConnectionState = pipelinesv1.LiveReconnecting
+	default:
+		status.ConnectionState = pipelinesv1.LiveConnecting
+	}
+
+	if !r.generationObserved(ingest) {
+		status.Conditions = append(status.Conditions, metav1.Condition{
+			Type:               string(pipelinesmeta.PipelineInSync),
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: ingest.GetGeneration(),
+			LastTransitionTime: metav1.Now(),
+			Reason:             string(pipelinesmeta.PipelineInSync),
+			Message:            "The pipeline configuration is in-sync",
+		})
+	}
+
+	if !equality.Semantic.DeepEqual(ingest.Status, *status) {
+		reqLogger.Info("Updating live ingest status", "ConnectionState", status.ConnectionState, "Restarts", status.Restarts)
+		ingest.Status = *status
+		if err := r.Client.Status().Update(ctx, ingest); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	reqLogger.Info("Reconcile finished")
+	return ctrl.Result{}, nil
+}
+
+// countRestarts returns the total restarts of the pipeline containers for the given ingest.
+func (r *LiveIngestReconciler) countRestarts(ctx context.Context, ingest *pipelinesv1.LiveIngest) (int32, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(ingest.GetNamespace()), client.MatchingLabels(ingest.GetDeploymentLabels())); err != nil {
+		return 0, err
+	}
+	var restarts int32
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+		}
+	}
+	return restarts, nil
+}
+
+func (r *LiveIngestReconciler) generationObserved(ingest *pipelinesv1.LiveIngest) bool {
+	for _, cond := range ingest.Status.Conditions {
+		if cond.ObservedGeneration == ingest.GetGeneration() {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager adds the LiveIngestReconciler to the given manager.
+func (r *LiveIngestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&pipelinesv1.LiveIngest{}).
+		Owns(&appsv1.Deployment{}).
+		Complete(r)
+}
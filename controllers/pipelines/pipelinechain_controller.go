@@ -0,0 +1,225 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+)
+
+// PipelineChainReconciler reconciles a PipelineChain object
+type PipelineChainReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=pipelinechains,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=pipelinechains/status,verbs=get;update;patch
+
+// Reconcile reconciles a PipelineChain. Jobs that finished in one stage of the chain have jobs
+// created for the next stage, and the progress of each stage is written to the status.
+func (r *PipelineChainReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("pipelinechain", req.NamespacedName)
+
+	// Fetch the object for the request
+	chain := &pipelinesv1.PipelineChain{}
+	err := r.Client.Get(ctx, req.NamespacedName, chain)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			// Object was deleted
+			return ctrl.Result{}, nil
+		}
+		// Requeue any other error
+		return ctrl.Result{}, err
+	}
+
+	if chain.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	status := chain.Status.DeepCopy()
+
+	// Fetch the Transforms for each stage
+	transforms := make([]*pipelinesv1.Transform, len(chain.Spec.Stages))
+	for idx, stage := range chain.Spec.Stages {
+		transform := &pipelinesv1.Transform{}
+		nn := types.NamespacedName{Name: stage.TransformRef, Namespace: chain.GetNamespace()}
+		if err := r.Client.Get(ctx, nn, transform); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+			reqLogger.Info("Transform for stage does not exist", "Stage", chain.GetStageName(idx), "Transform", stage.TransformRef)
+			setChainCondition(chain, status, metav1.ConditionFalse, "TransformNotFound",
+				fmt.Sprintf("The Transform %q for stage %q does not exist", stage.TransformRef, chain.GetStageName(idx)))
+			return ctrl.Result{}, r.updateStatus(ctx, chain, status)
+		}
+		// Later stages get their jobs from the chain, a bucket watch of their own would process
+		// every object twice
+		if idx > 0 && transform.Spec.Src != nil {
+			reqLogger.Info("Transform for later stage has a src configuration", "Stage", chain.GetStageName(idx), "Transform", stage.TransformRef)
+			setChainCondition(chain, status, metav1.ConditionFalse, "StageHasSource",
+				fmt.Sprintf("The Transform %q for stage %q has a src configuration, Transforms used after the first stage must omit it", stage.TransformRef, chain.GetStageName(idx)))
+			return ctrl.Result{}, r.updateStatus(ctx, chain, status)
+		}
+		transforms[idx] = transform
+	}
+
+	// List the jobs in the namespace and sort out the ones belonging to this chain
+	jobs := &pipelinesv1.JobList{}
+	if err := r.Client.List(ctx, jobs, client.InNamespace(chain.GetNamespace())); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status.Stages = make([]pipelinesv1.ChainStageStatus, len(chain.Spec.Stages))
+	for idx := range chain.Spec.Stages {
+		status.Stages[idx].Name = chain.GetStageName(idx)
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		stage, ok := chain.StageForJob(job)
+		if !ok {
+			continue
+		}
+		stageStatus := &status.Stages[stage]
+		switch job.GetState() {
+		case pipelinesv1.JobPending:
+			stageStatus.Pending++
+		case pipelinesv1.JobInProgress:
+			stageStatus.InProgress++
+		case pipelinesv1.JobFailed:
+			stageStatus.Failed++
+		case pipelinesv1.JobFinished:
+			stageStatus.Finished++
+			if stage == len(chain.Spec.Stages)-1 {
+				continue
+			}
+			for _, next := range chain.NewStageJobs(stage+1, job, transforms[stage+1]) {
+				if err := r.Client.Create(ctx, next); err != nil {
+					if errors.IsAlreadyExists(err) {
+						continue
+					}
+					return ctrl.Result{}, err
+				}
+				reqLogger.Info("Created job for next stage", "Stage", chain.GetStageName(stage+1), "Job", next.GetName(), "Source", next.Spec.Source.Name)
+			}
+		}
+	}
+
+	setChainCondition(chain, status, metav1.ConditionTrue, string(pipelinesmeta.PipelineInSync), "The pipeline chain is in-sync")
+
+	reqLogger.Info("Reconcile finished")
+	return ctrl.Result{}, r.updateStatus(ctx, chain, status)
+}
+
+func (r *PipelineChainReconciler) updateStatus(ctx context.Context, chain *pipelinesv1.PipelineChain, status *pipelinesv1.PipelineChainStatus) error {
+	if equality.Semantic.DeepEqual(chain.Status, *status) {
+		return nil
+	}
+	chain.Status = *status
+	return r.Client.Status().Update(ctx, chain)
+}
+
+// setChainCondition appends a condition to the status unless the latest condition already
+// reports the same state for the current generation.
+func setChainCondition(chain *pipelinesv1.PipelineChain, status *pipelinesv1.PipelineChainStatus, condStatus metav1.ConditionStatus, reason, message string) {
+	if len(status.Conditions) > 0 {
+		last := status.Conditions[len(status.Conditions)-1]
+		if last.ObservedGeneration == chain.GetGeneration() && last.Status == condStatus && last.Message == message {
+			return
+		}
+	}
+	status.Conditions = append(status.Conditions, metav1.Condition{
+		Type:               string(pipelinesmeta.PipelineInSync),
+		Status:             condStatus,
+		ObservedGeneration: chain.GetGeneration(),
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// chainsForJob maps a pipeline job to the chains it belongs to.
+func (r *PipelineChainReconciler) chainsForJob(obj client.Object) []reconcile.Request {
+	job, ok := obj.(*pipelinesv1.Job)
+	if !ok {
+		return nil
+	}
+	if chain, ok := job.GetLabels()[pipelinesmeta.JobChainLabel]; ok {
+		return []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: chain, Namespace: job.GetNamespace()}},
+		}
+	}
+	return r.listChains(job.GetNamespace(), func(chain *pipelinesv1.PipelineChain) bool {
+		_, ok := chain.StageForJob(job)
+		return ok
+	})
+}
+
+// chainsForTransform maps a Transform to the chains that reference it in any stage.
+func (r *PipelineChainReconciler) chainsForTransform(obj client.Object) []reconcile.Request {
+	return r.listChains(obj.GetNamespace(), func(chain *pipelinesv1.PipelineChain) bool {
+		for _, stage := range chain.Spec.Stages {
+			if stage.TransformRef == obj.GetName() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *PipelineChainReconciler) listChains(namespace string, match func(*pipelinesv1.PipelineChain) bool) []reconcile.Request {
+	chains := &pipelinesv1.PipelineChainList{}
+	if err := r.Client.List(context.TODO(), chains, client.InNamespace(namespace)); err != nil {
+		r.Log.Error(err, "Failed to list pipeline chains", "Namespace", namespace)
+		return nil
+	}
+	reqs := make([]reconcile.Request, 0)
+	for i := range chains.Items {
+		if match(&chains.Items[i]) {
+			reqs = append(reqs, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: chains.Items[i].GetName(), Namespace: namespace},
+			})
+		}
+	}
+	return reqs
+}
+
+// SetupWithManager adds the PipelineChainReconciler to the given manager.
+func (r *PipelineChainReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&pipelinesv1.PipelineChain{}).
+		Watches(&source.Kind{Type: &pipelinesv1.Job{}}, handler.EnqueueRequestsFromMapFunc(r.chainsForJob)).
+		Watches(&source.Kind{Type: &pipelinesv1.Transform{}}, handler.EnqueueRequestsFromMapFunc(r.chainsForTransform)).
+		Complete(r)
+}
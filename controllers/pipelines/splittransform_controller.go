@@ -20,10 +20,13 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
 	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
@@ -67,9 +70,25 @@ func (r *SplitTransformReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, r.removeFinalizers(ctx, reqLogger, pipeline)
 	}
 
+	// Render the effective pipeline configuration if a template is referenced. This happens before
+	// the manager is started, so it never creates jobs for a pipeline that is not rendered yet.
+	if cfg := pipeline.Spec.Pipeline; cfg != nil && cfg.Template != nil {
+		rendered, err := renderPipelineTemplate(ctx, r.Client, pipeline.GetNamespace(), cfg)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !equality.Semantic.DeepEqual(rendered, pipeline.Status.Pipeline) {
+			reqLogger.Info("Updating pipeline configuration rendered from template", "Template", cfg.Template.Name)
+			pipeline.Status.Pipeline = rendered
+			if err := r.Client.Status().Update(ctx, pipeline); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
 	if !controller.IsRunning() {
 		reqLogger.Info("Starting PipelineManager")
-		if err := controller.Start(); err != nil {
+		if err := controller.Start(pipeline); err != nil {
 			return ctrl.Result{}, err
 		}
 	} else {
@@ -103,6 +122,7 @@ func (r *SplitTransformReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 func (r *SplitTransformReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&pipelinesv1.SplitTransform{}).
+		Watches(&source.Kind{Type: &pipelinesv1.PipelineTemplate{}}, handler.EnqueueRequestsFromMapFunc(splitTransformsForTemplate(r.Client))).
 		Complete(r)
 }
 
@@ -0,0 +1,84 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+)
+
+// +kubebuilder:rbac:groups=pipelines.gst.io,resources=pipelinetemplates,verbs=get;list;watch
+
+// renderPipelineTemplate fetches the template referenced by the given configuration and renders
+// the effective pipeline configuration.
+func renderPipelineTemplate(ctx context.Context, c client.Client, namespace string, cfg *pipelinesmeta.PipelineConfig) (*pipelinesmeta.PipelineConfig, error) {
+	tmpl := &pipelinesv1.PipelineTemplate{}
+	nn := types.NamespacedName{Name: cfg.Template.Name, Namespace: namespace}
+	if err := c.Get(ctx, nn, tmpl); err != nil {
+		return nil, err
+	}
+	return tmpl.Render(cfg)
+}
+
+// referencesTemplate returns true if the given configuration uses the named template.
+func referencesTemplate(cfg *pipelinesmeta.PipelineConfig, name string) bool {
+	return cfg != nil && cfg.Template != nil && cfg.Template.Name == name
+}
+
+// transformsForTemplate maps a PipelineTemplate to the Transforms that reference it.
+func transformsForTemplate(c client.Client) func(client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		pipelines := &pipelinesv1.TransformList{}
+		if err := c.List(context.TODO(), pipelines, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0)
+		for _, pipeline := range pipelines.Items {
+			if referencesTemplate(pipeline.Spec.Pipeline, obj.GetName()) {
+				reqs = append(reqs, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: pipeline.GetName(), Namespace: pipeline.GetNamespace()},
+				})
+			}
+		}
+		return reqs
+	}
+}
+
+// splitTransformsForTemplate maps a PipelineTemplate to the SplitTransforms that reference it.
+func splitTransformsForTemplate(c client.Client) func(client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		pipelines := &pipelinesv1.SplitTransformList{}
+		if err := c.List(context.TODO(), pipelines, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0)
+		for _, pipeline := range pipelines.Items {
+			if referencesTemplate(pipeline.Spec.Pipeline, obj.GetName()) {
+				reqs = append(reqs, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: pipeline.GetName(), Namespace: pipeline.GetNamespace()},
+				})
+			}
+		}
+		return reqs
+	}
+}
@@ -20,10 +20,14 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
 	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
@@ -42,6 +46,8 @@ type TransformReconciler struct {
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=pipelines.gst.io,resources=transforms,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=pipelines.gst.io,resources=transforms/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 
 // Reconcile reconciles a Transform pipeline
 func (r *TransformReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -70,9 +76,47 @@ func (r *TransformReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, r.removeFinalizers(ctx, reqLogger, pipeline)
 	}
 
-	if !controller.IsRunning() {
+	if schedule := pipeline.GetSchedule(); schedule != nil {
+		if _, err := schedule.Parse(); err != nil {
+			// Retrying cannot help until the spec changes, so the error is only reported on the status
+			reqLogger.Info("Pipeline has an invalid schedule", "Error", err.Error())
+			// Do not keep processing on a schedule the status no longer reports
+			if controller.IsRunning() {
+				reqLogger.Info("Stopping PipelineManager until the schedule is fixed")
+				controller.Stop()
+			}
+			return ctrl.Result{}, r.setCondition(ctx, pipeline, pipelinesmeta.PipelineScheduled, metav1.ConditionFalse, "InvalidSchedule", err.Error())
+		}
+		if err := r.setCondition(ctx, pipeline, pipelinesmeta.PipelineScheduled, metav1.ConditionTrue, "ScheduleValid", "The schedule of the pipeline is valid"); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Render the effective pipeline configuration if a template is referenced. This happens before
+	// the manager is started, so it never creates jobs for a pipeline that is not rendered yet.
+	if cfg := pipeline.Spec.Pipeline; cfg != nil && cfg.Template != nil {
+		rendered, err := renderPipelineTemplate(ctx, r.Client, pipeline.GetNamespace(), cfg)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !equality.Semantic.DeepEqual(rendered, pipeline.Status.Pipeline) {
+			reqLogger.Info("Updating pipeline configuration rendered from template", "Template", cfg.Template.Name)
+			pipeline.Status.Pipeline = rendered
+			if err := r.Client.Status().Update(ctx, pipeline); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if pipeline.Spec.Src == nil {
+		// The pipeline is only run by PipelineChains
+		if controller.IsRunning() {
+			reqLogger.Info("Pipeline has no src configuration, stopping PipelineManager")
+			controller.Stop()
+		}
+	} else if !controller.IsRunning() {
 		reqLogger.Info("Starting PipelineManager")
-		if err := controller.Start(); err != nil {
+		if err := controller.Start(pipeline); err != nil {
 			return ctrl.Result{}, err
 		}
 	} else {
@@ -98,19 +142,49 @@ func (r *TransformReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	if pipeline.Spec.Validation != nil {
+		if err := r.reconcileValidation(ctx, reqLogger, pipeline); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	reqLogger.Info("Reconcile finished")
 	return ctrl.Result{}, nil
 }
 
 func (r *TransformReconciler) generationObserved(pipeline *pipelinesv1.Transform) bool {
 	for _, cond := range pipeline.Status.Conditions {
-		if cond.ObservedGeneration == pipeline.GetGeneration() {
+		if cond.Type == string(pipelinesmeta.PipelineInSync) && cond.ObservedGeneration == pipeline.GetGeneration() {
 			return true
 		}
 	}
 	return false
 }
 
+// setCondition appends a condition of the given type to the status of the Transform unless the latest
+// one for this generation is the same.
+func (r *TransformReconciler) setCondition(ctx context.Context, pipeline *pipelinesv1.Transform, condType pipelinesmeta.PipelineState, status metav1.ConditionStatus, reason, message string) error {
+	for idx := len(pipeline.Status.Conditions) - 1; idx >= 0; idx-- {
+		cond := pipeline.Status.Conditions[idx]
+		if cond.Type != string(condType) {
+			continue
+		}
+		if cond.ObservedGeneration == pipeline.GetGeneration() && cond.Status == status && cond.Reason == reason && cond.Message == message {
+			return nil
+		}
+		break
+	}
+	pipeline.Status.Conditions = append(pipeline.Status.Conditions, metav1.Condition{
+		Type:               string(condType),
+		Status:             status,
+		ObservedGeneration: pipeline.GetGeneration(),
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	return r.Client.Status().Update(ctx, pipeline)
+}
+
 func (r *TransformReconciler) removeFinalizers(ctx context.Context, reqLogger logr.Logger, pipeline *pipelinesv1.Transform) error {
 	pipeline.SetFinalizers([]string{})
 	return r.Client.Update(ctx, pipeline)
@@ -139,6 +213,8 @@ func contains(ss []string, s string) bool {
 func (r *TransformReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&pipelinesv1.Transform{}).
+		Watches(&source.Kind{Type: &pipelinesv1.PipelineTemplate{}}, handler.EnqueueRequestsFromMapFunc(transformsForTemplate(r.Client))).
 		Owns(&pipelinesv1.Job{}).
+		Owns(&batchv1.Job{}).
 		Complete(r)
 }
@@ -0,0 +1,151 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+	pipelinesv1 "github.com/tinyzimmer/gst-pipeline-operator/apis/pipelines/v1"
+)
+
+var validationBackoffLimit int32 = 0
+
+// reconcileValidation runs a one-off job validating the configuration of the current generation of
+// the Transform, and reflects the report it produces in a Valid condition.
+func (r *TransformReconciler) reconcileValidation(ctx context.Context, reqLogger logr.Logger, pipeline *pipelinesv1.Transform) error {
+	name := fmt.Sprintf("%s-validate-%d", pipeline.GetName(), pipeline.GetGeneration())
+
+	found := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: pipeline.GetNamespace()}, found)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		// Jobs for previous generations are no longer relevant
+		previous := &batchv1.JobList{}
+		if err := r.Client.List(ctx, previous, client.InNamespace(pipeline.GetNamespace()), client.MatchingLabels{pipelinesmeta.JobValidationLabel: pipeline.GetName()}); err != nil {
+			return err
+		}
+		for idx := range previous.Items {
+			reqLogger.Info("Deleting validation Job for previous generation", "Name", previous.Items[idx].GetName())
+			if err := r.Client.Delete(ctx, &previous.Items[idx], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		job, err := newValidationJob(name, pipeline)
+		if err != nil {
+			return err
+		}
+		reqLogger.Info("Creating validation Job", "Name", name)
+		if err := r.Client.Create(ctx, job); err != nil {
+			return err
+		}
+		return r.setValidCondition(ctx, pipeline, metav1.ConditionUnknown, "ValidationPending", "The pipeline configuration is being validated")
+	}
+
+	if jobInProgress(found) {
+		return nil
+	}
+
+	report, err := r.getValidationReport(ctx, found)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return r.setValidCondition(ctx, pipeline, metav1.ConditionFalse, "ValidationFailed", "The validation job did not produce a report")
+	}
+	if report.Valid && report.Partial {
+		return r.setValidCondition(ctx, pipeline, metav1.ConditionTrue, "ValidationPartial", report.String())
+	}
+	if report.Valid {
+		return r.setValidCondition(ctx, pipeline, metav1.ConditionTrue, "ValidationSucceeded", report.String())
+	}
+	return r.setValidCondition(ctx, pipeline, metav1.ConditionFalse, "ValidationFailed", report.String())
+}
+
+// getValidationReport reads the report from the termination message of the validation job's pod.
+// Nil is returned if no pod left a report.
+func (r *TransformReconciler) getValidationReport(ctx context.Context, job *batchv1.Job) (*pipelinesmeta.ValidationReport, error) {
+	msg, err := getTerminationMessage(ctx, r.Client, job)
+	if err != nil || msg == "" {
+		return nil, err
+	}
+	report := &pipelinesmeta.ValidationReport{}
+	if err := json.Unmarshal([]byte(msg), report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// setValidCondition records the outcome of validating the Transform in a Valid condition.
+func (r *TransformReconciler) setValidCondition(ctx context.Context, pipeline *pipelinesv1.Transform, status metav1.ConditionStatus, reason, message string) error {
+	return r.setCondition(ctx, pipeline, pipelinesmeta.PipelineValid, status, reason, message)
+}
+
+// newValidationJob returns a job running the pipeline of the Transform in validation mode. The
+// configured sample object is used as its source when there is one.
+func newValidationJob(name string, pipeline *pipelinesv1.Transform) (*batchv1.Job, error) {
+	srcConfig := pipeline.GetSrcConfig()
+	if pipeline.Spec.Src == nil {
+		// Transforms run only by chains read from the sink of an earlier stage
+		srcConfig = pipeline.GetSinkConfig()
+	}
+	sampleKey := pipeline.Spec.Validation.SampleKey
+
+	pipelineJob := &pipelinesv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pipeline.GetNamespace(),
+			Labels: map[string]string{
+				pipelinesmeta.JobValidationLabel: pipeline.GetName(),
+			},
+		},
+		Spec: pipelinesv1.JobSpec{
+			Source: &pipelinesmeta.Object{
+				Name:       sampleKey,
+				Config:     srcConfig,
+				StreamType: pipelinesmeta.StreamTypeAll,
+			},
+			Sinks: pipeline.GetSinkObjects(sampleKey),
+		},
+	}
+
+	job, err := newPipelineJob(pipelineJob, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	job.SetOwnerReferences(pipeline.OwnerReferences())
+	job.Spec.BackoffLimit = &validationBackoffLimit
+	job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.TerminationMessagePath = pipelinesmeta.TerminationMessagePath
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  pipelinesmeta.JobValidateEnvVar,
+		Value: "true",
+	})
+	return job, nil
+}
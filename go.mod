@@ -14,6 +14,8 @@ require (
 	github.com/onsi/ginkgo v1.14.1
 	github.com/onsi/gomega v1.10.2
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.7.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/russross/blackfriday/v2 v2.0.1
 	github.com/tinyzimmer/go-glib v0.0.19
 	github.com/tinyzimmer/go-gst v0.2.12
@@ -3,15 +3,22 @@ package main
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync/atomic"
 
 	minio "github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	"github.com/tinyzimmer/go-glib/glib"
 	"github.com/tinyzimmer/go-gst/gst"
+
+	"github.com/tinyzimmer/gst-pipeline-operator/gst/plugins/minio/s3io"
 )
 
 const (
@@ -19,6 +26,13 @@ const (
 	secretAccessKeyEnvVar = "MINIO_SECRET_ACCESS_KEY"
 )
 
+// Values for the sse-type property
+const (
+	sseTypeS3  = "SSE-S3"
+	sseTypeKMS = "SSE-KMS"
+	sseTypeC   = "SSE-C"
+)
+
 var (
 	defaultEndpoint           = "play.min.io"
 	defaultUseTLS             = true
@@ -27,16 +41,31 @@ var (
 )
 
 type settings struct {
-	endpoint           string
-	useTLS             bool
-	region             string
-	bucket             string
-	key                string
-	accessKeyID        string
-	secretAccessKey    string
-	insecureSkipVerify bool
-	caCertFile         string
-	partSize           uint64
+	endpoint            string
+	useTLS              bool
+	region              string
+	bucket              string
+	key                 string
+	versionID           string
+	etag                string
+	accessKeyID         string
+	secretAccessKey     string
+	insecureSkipVerify  bool
+	caCertFile          string
+	partSize            uint64
+	uploadConcurrency   uint
+	uploadBufferSize    uint64
+	readAheadChunkSize  uint64
+	readAheadBufferSize uint64
+	contentType         string
+	contentDisposition  string
+	cacheControl        string
+	metadata            string
+	tags                string
+	storageClass        string
+	sseType             string
+	sseKMSKeyID         string
+	sseCustomerKey      string
 }
 
 func (s *settings) safestring() string {
@@ -46,23 +75,125 @@ func (s *settings) safestring() string {
 		region:             s.region,
 		bucket:             s.bucket,
 		key:                s.key,
+		versionID:          s.versionID,
+		etag:               s.etag,
 		insecureSkipVerify: s.insecureSkipVerify,
 		caCertFile:         s.caCertFile,
 	})
 }
 
+// resolved returns a copy of the settings with the credentials given as env: references replaced by
+// the values of the environment variables they name.
+func (s *settings) resolved() *settings {
+	resolved := *s
+	resolved.accessKeyID = resolveEnv(s.accessKeyID)
+	resolved.secretAccessKey = resolveEnv(s.secretAccessKey)
+	resolved.sseCustomerKey = resolveEnv(s.sseCustomerKey)
+	return &resolved
+}
+
+// resolveEnv returns the value of the environment variable named by value if it has the env: prefix,
+// or value itself otherwise.
+func resolveEnv(value string) string {
+	if strings.HasPrefix(value, "env:") {
+		return os.Getenv(strings.TrimPrefix(value, "env:"))
+	}
+	return value
+}
+
 func defaultSettings() *settings {
 	return &settings{
-		endpoint:           defaultEndpoint,
-		useTLS:             defaultUseTLS,
-		region:             defaultRegion,
-		accessKeyID:        os.Getenv(accessKeyIDEnvVar),
-		secretAccessKey:    os.Getenv(secretAccessKeyEnvVar),
-		insecureSkipVerify: defaultInsecureSkipVerify,
-		partSize:           defaultPartSize,
+		endpoint:            defaultEndpoint,
+		useTLS:              defaultUseTLS,
+		region:              defaultRegion,
+		accessKeyID:         os.Getenv(accessKeyIDEnvVar),
+		secretAccessKey:     os.Getenv(secretAccessKeyEnvVar),
+		insecureSkipVerify:  defaultInsecureSkipVerify,
+		partSize:            defaultPartSize,
+		uploadConcurrency:   defaultUploadConcurrency,
+		uploadBufferSize:    defaultUploadBufferSize,
+		readAheadChunkSize:  defaultReadAheadChunkSize,
+		readAheadBufferSize: defaultReadAheadBufferSize,
+	}
+}
+
+// putObjectOptions returns the options for storing an object written with the settings.
+func (s *settings) putObjectOptions() (minio.PutObjectOptions, error) {
+	opts := minio.PutObjectOptions{
+		ContentType:        s.contentType,
+		ContentDisposition: s.contentDisposition,
+		CacheControl:       s.cacheControl,
+		StorageClass:       s.storageClass,
+	}
+	var err error
+	if opts.ServerSideEncryption, err = s.serverSideEncryption(); err != nil {
+		return opts, err
+	}
+	if opts.UserMetadata, err = parseValues(s.metadata); err != nil {
+		return opts, fmt.Errorf("Invalid metadata %q: %s", s.metadata, err.Error())
+	}
+	if opts.UserTags, err = parseValues(s.tags); err != nil {
+		return opts, fmt.Errorf("Invalid tags %q: %s", s.tags, err.Error())
+	}
+	return opts, nil
+}
+
+// serverSideEncryption returns the encryption configured in the settings, or nil if there is none.
+// A customer key without a type implies SSE-C.
+func (s *settings) serverSideEncryption() (encrypt.ServerSide, error) {
+	sseType := s.sseType
+	if sseType == "" && s.sseCustomerKey != "" {
+		sseType = sseTypeC
+	}
+	switch sseType {
+	case "":
+		return nil, nil
+	case sseTypeS3:
+		return encrypt.NewSSE(), nil
+	case sseTypeKMS:
+		return encrypt.NewSSEKMS(s.sseKMSKeyID, nil)
+	case sseTypeC:
+		key, err := base64.StdEncoding.DecodeString(s.sseCustomerKey)
+		if err != nil {
+			return nil, fmt.Errorf("The SSE-C customer key is not valid base64: %s", err.Error())
+		}
+		return encrypt.NewSSEC(key)
+	}
+	return nil, fmt.Errorf("Unknown sse-type %q, must be one of %s, %s or %s", sseType, sseTypeS3, sseTypeKMS, sseTypeC)
+}
+
+// categoryLogger returns an s3io.Logger that logs to the given debug category.
+func categoryLogger(cat *gst.DebugCategory) s3io.Logger {
+	return func(level s3io.Level, msg string) {
+		switch level {
+		case s3io.LevelInfo:
+			cat.Log(gst.LevelInfo, msg)
+		case s3io.LevelDebug:
+			cat.Log(gst.LevelDebug, msg)
+		case s3io.LevelLog:
+			cat.Log(gst.LevelLog, msg)
+		default:
+			cat.Log(gst.LevelTrace, msg)
+		}
 	}
 }
 
+// parseValues parses URL-encoded key=value pairs, returning nil if there are none.
+func parseValues(encoded string) (map[string]string, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	query, err := url.ParseQuery(encoded)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(query))
+	for k := range query {
+		values[k] = query.Get(k)
+	}
+	return values, nil
+}
+
 func getMinIOClient(settings *settings) (*minio.Client, error) {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 
@@ -110,14 +241,44 @@ func setProperty(elem *gst.Element, properties []*glib.ParamSpec, settings *sett
 		settings.region = val.(string)
 	case "bucket":
 		settings.bucket = val.(string)
-	case "key":
+	case "key", "location":
 		settings.key = val.(string)
+	case "version-id":
+		settings.versionID = val.(string)
+	case "etag":
+		settings.etag = val.(string)
 	case "access-key-id":
 		settings.accessKeyID = val.(string)
 	case "secret-access-key":
 		settings.secretAccessKey = val.(string)
 	case "part-size":
 		settings.partSize = val.(uint64)
+	case "upload-concurrency":
+		settings.uploadConcurrency = val.(uint)
+	case "upload-buffer-size":
+		settings.uploadBufferSize = val.(uint64)
+	case "read-ahead-chunk-size":
+		settings.readAheadChunkSize = val.(uint64)
+	case "read-ahead-buffer-size":
+		settings.readAheadBufferSize = val.(uint64)
+	case "content-type":
+		settings.contentType = val.(string)
+	case "content-disposition":
+		settings.contentDisposition = val.(string)
+	case "cache-control":
+		settings.cacheControl = val.(string)
+	case "metadata":
+		settings.metadata = val.(string)
+	case "tags":
+		settings.tags = val.(string)
+	case "storage-class":
+		settings.storageClass = val.(string)
+	case "sse-type":
+		settings.sseType = val.(string)
+	case "sse-kms-key-id":
+		settings.sseKMSKeyID = val.(string)
+	case "sse-customer-key":
+		settings.sseCustomerKey = val.(string)
 	}
 }
 
@@ -139,14 +300,38 @@ func getProperty(elem *gst.Element, properties []*glib.ParamSpec, settings *sett
 		localVal = settings.region
 	case "bucket":
 		localVal = settings.bucket
-	case "key":
+	case "key", "location":
 		localVal = settings.key
-	case "access-key-id":
-		localVal = settings.accessKeyID
-	case "secret-access-key":
-		localVal = "<private>"
+	case "version-id":
+		localVal = settings.versionID
+	case "etag":
+		localVal = settings.etag
 	case "part-size":
 		localVal = settings.partSize
+	case "upload-concurrency":
+		localVal = settings.uploadConcurrency
+	case "upload-buffer-size":
+		localVal = settings.uploadBufferSize
+	case "read-ahead-chunk-size":
+		localVal = settings.readAheadChunkSize
+	case "read-ahead-buffer-size":
+		localVal = settings.readAheadBufferSize
+	case "content-type":
+		localVal = settings.contentType
+	case "content-disposition":
+		localVal = settings.contentDisposition
+	case "cache-control":
+		localVal = settings.cacheControl
+	case "metadata":
+		localVal = settings.metadata
+	case "tags":
+		localVal = settings.tags
+	case "storage-class":
+		localVal = settings.storageClass
+	case "sse-type":
+		localVal = settings.sseType
+	case "sse-kms-key-id":
+		localVal = settings.sseKMSKeyID
 
 	default:
 		elem.ErrorMessage(gst.DomainLibrary, gst.LibraryErrorSettings,
@@ -165,3 +350,13 @@ func getProperty(elem *gst.Element, properties []*glib.ParamSpec, settings *sett
 
 	return val
 }
+
+// getCounterProperty returns the value of a read-only counter property.
+func getCounterProperty(elem *gst.Element, counter *uint64) *glib.Value {
+	val, err := glib.GValue(atomic.LoadUint64(counter))
+	if err != nil {
+		elem.ErrorMessage(gst.DomainLibrary, gst.LibraryErrorFailed, "Could not convert counter to GValue", err.Error())
+		return nil
+	}
+	return val
+}
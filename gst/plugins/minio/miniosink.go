@@ -3,13 +3,15 @@ package main
 import (
 	"fmt"
 	"io"
-	"os"
-	"strings"
 	"sync"
 
+	minio "github.com/minio/minio-go/v7"
+
 	"github.com/tinyzimmer/go-glib/glib"
 	"github.com/tinyzimmer/go-gst/gst"
 	"github.com/tinyzimmer/go-gst/gst/base"
+
+	"github.com/tinyzimmer/gst-pipeline-operator/gst/plugins/minio/s3io"
 )
 
 var sinkCAT = gst.NewDebugCategory(
@@ -22,11 +24,14 @@ type minioSink struct {
 	settings *settings
 	state    *sinkstate
 
-	writer *seekWriter
+	writer *s3io.SeekWriter
 	mux    sync.Mutex
 }
 
 type sinkstate struct {
+	// accessed atomically by the writer, kept first for alignment
+	bytesUploaded uint64
+
 	started bool
 }
 
@@ -64,6 +69,9 @@ func (m *minioSink) SetProperty(self *glib.Object, id uint, value *glib.Value) {
 }
 
 func (m *minioSink) GetProperty(self *glib.Object, id uint) *glib.Value {
+	if sinkProperties[id].Name() == "bytes-uploaded" {
+		return getCounterProperty(gst.ToElement(self), &m.state.bytesUploaded)
+	}
 	return getProperty(gst.ToElement(self), sinkProperties, m.settings, id)
 }
 
@@ -97,24 +105,17 @@ func (m *minioSink) Start(self *base.GstBaseSink) bool {
 
 	if m.settings.key == "" {
 		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorSettings,
-			"No bucket configured on the miniosink", "")
+			"No object key configured on the miniosink", "")
 		return false
 	}
 
 	self.Log(sinkCAT, gst.LevelDebug, m.settings.safestring())
 
-	if strings.HasPrefix(m.settings.accessKeyID, "env:") {
-		spl := strings.Split(m.settings.accessKeyID, "env:")
-		m.settings.accessKeyID = os.Getenv(spl[len(spl)-1])
-	}
-
-	if strings.HasPrefix(m.settings.secretAccessKey, "env:") {
-		spl := strings.Split(m.settings.secretAccessKey, "env:")
-		m.settings.secretAccessKey = os.Getenv(spl[len(spl)-1])
-	}
+	// The properties keep any env: references, so the resolved secrets are never readable
+	settings := m.settings.resolved()
 
 	self.Log(sinkCAT, gst.LevelInfo, fmt.Sprintf("Creating new MinIO client for %s", m.settings.endpoint))
-	client, err := getMinIOClient(m.settings)
+	client, err := getMinIOClient(settings)
 	if err != nil {
 		self.Log(sinkCAT, gst.LevelError, err.Error())
 		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorFailed,
@@ -122,8 +123,23 @@ func (m *minioSink) Start(self *base.GstBaseSink) bool {
 		return false
 	}
 
+	opts, err := settings.putObjectOptions()
+	if err != nil {
+		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorSettings, err.Error(), "")
+		return false
+	}
+
 	self.Log(sinkCAT, gst.LevelInfo, "Initializing new MinIO writer")
-	m.writer = newSeekWriter(client, int64(m.settings.partSize), m.settings.bucket, m.settings.key)
+	m.writer = s3io.NewSeekWriter(minio.Core{Client: client}, s3io.SeekWriterConfig{
+		Bucket:            settings.bucket,
+		Key:               settings.key,
+		PartSize:          int64(settings.partSize),
+		UploadConcurrency: int(settings.uploadConcurrency),
+		UploadBufferSize:  int64(settings.uploadBufferSize),
+		Options:           opts,
+		Uploaded:          &m.state.bytesUploaded,
+		Logger:            categoryLogger(sinkCAT),
+	})
 
 	m.state.started = true
 	self.Log(sinkCAT, gst.LevelInfo, "MinIOSink has started")
@@ -140,6 +156,12 @@ func (m *minioSink) Stop(self *base.GstBaseSink) bool {
 		return false
 	}
 
+	// The upload is only left incomplete if the sink is stopped without an EOS, such as when
+	// the pipeline is aborted.
+	if err := m.writer.Abort(); err != nil {
+		self.Log(sinkCAT, gst.LevelWarning, fmt.Sprintf("Failed to abort the multipart upload: %s", err.Error()))
+	}
+
 	m.writer = nil
 	m.state.started = false
 
@@ -171,11 +193,19 @@ func (m *minioSink) Event(self *base.GstBaseSink, event *gst.Event) bool {
 
 	switch event.Type() {
 
+	case gst.EventTypeCaps:
+		if contentType := contentTypeForCaps(event.ParseCaps()); contentType != "" {
+			self.Log(sinkCAT, gst.LevelDebug, fmt.Sprintf("Using content type %s for negotiated caps", contentType))
+			m.mux.Lock()
+			m.writer.SetDefaultContentType(contentType)
+			m.mux.Unlock()
+		}
+
 	case gst.EventTypeSegment:
 		segment := event.ParseSegment()
 
 		if segment.GetFormat() == gst.FormatBytes {
-			if uint64(m.writer.currentPosition) != segment.GetStart() {
+			if uint64(m.writer.Position()) != segment.GetStart() {
 				m.mux.Lock()
 				self.Log(sinkCAT, gst.LevelInfo, fmt.Sprintf("Seeking to %d", segment.GetStart()))
 				if _, err := m.writer.Seek(int64(segment.GetStart()), io.SeekStart); err != nil {
@@ -192,14 +222,10 @@ func (m *minioSink) Event(self *base.GstBaseSink, event *gst.Event) bool {
 		}
 
 	case gst.EventTypeFlushStop:
-		self.Log(sinkCAT, gst.LevelInfo, "Flushing contents of writer and seeking back to start")
-		if m.writer.currentPosition != 0 {
+		self.Log(sinkCAT, gst.LevelInfo, "Seeking writer back to start")
+		if m.writer.Position() != 0 {
 			m.mux.Lock()
-			if err := m.writer.flush(true); err != nil {
-				self.ErrorMessage(gst.DomainResource, gst.ResourceErrorWrite, err.Error(), "")
-				m.mux.Unlock()
-				return false
-			}
+			// Parts are kept in memory so they can still be rewritten, nothing is uploaded until they fill up
 			if _, err := m.writer.Seek(0, io.SeekStart); err != nil {
 				self.ErrorMessage(gst.DomainResource, gst.ResourceErrorFailed, err.Error(), "")
 				m.mux.Unlock()
@@ -226,3 +252,41 @@ func (m *minioSink) Event(self *base.GstBaseSink, event *gst.Event) bool {
 	return self.ParentEvent(event)
 
 }
+
+// contentTypes maps the media types of caps to the content types of objects holding them, where
+// the two differ.
+var contentTypes = map[string]string{
+	"video/mpegts":               "video/mp2t",
+	"audio/x-flac":               "audio/flac",
+	"audio/x-wav":                "audio/wav",
+	"application/x-subtitle-vtt": "text/vtt",
+	"application/x-hls":          "application/vnd.apple.mpegurl",
+}
+
+// contentTypeForCaps returns the content type of an object holding a stream with the given caps, or
+// an empty string if there are none.
+func contentTypeForCaps(caps *gst.Caps) string {
+	if caps == nil || caps.GetSize() == 0 {
+		return ""
+	}
+	st := caps.GetStructureAt(0)
+	name := st.Name()
+	switch name {
+	case "video/quicktime":
+		variant, _ := st.GetValue("variant")
+		switch variant {
+		case "iso", "iso-fragmented":
+			return "video/mp4"
+		case "3gpp":
+			return "video/3gpp"
+		}
+	case "audio/mpeg":
+		if version, _ := st.GetValue("mpegversion"); version == 2 || version == 4 {
+			return "audio/aac"
+		}
+	}
+	if contentType, ok := contentTypes[name]; ok {
+		return contentType
+	}
+	return name
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	minio "github.com/minio/minio-go/v7"
 
 	"github.com/tinyzimmer/go-glib/glib"
 	"github.com/tinyzimmer/go-gst/gst"
 	"github.com/tinyzimmer/go-gst/gst/base"
+
+	"github.com/tinyzimmer/gst-pipeline-operator/gst/plugins/minio/s3io"
 )
 
 var srcCAT = gst.NewDebugCategory(
@@ -27,9 +29,15 @@ type minioSrc struct {
 }
 
 type srcstate struct {
+	// accessed atomically, kept first for alignment
+	bytesRead    uint64
+	bytesFetched uint64
+
 	started bool
 	object  *minio.Object
 	objInfo minio.ObjectInfo
+	// nil when read-ahead is disabled
+	readAhead *s3io.ReadAhead
 
 	mux sync.Mutex
 }
@@ -66,6 +74,12 @@ func (m *minioSrc) SetProperty(self *glib.Object, id uint, value *glib.Value) {
 }
 
 func (m *minioSrc) GetProperty(self *glib.Object, id uint) *glib.Value {
+	switch srcProperties[id].Name() {
+	case "bytes-read":
+		return getCounterProperty(gst.ToElement(self), &m.state.bytesRead)
+	case "bytes-fetched":
+		return getCounterProperty(gst.ToElement(self), &m.state.bytesFetched)
+	}
 	return getProperty(gst.ToElement(self), srcProperties, m.settings, id)
 }
 
@@ -102,17 +116,10 @@ func (m *minioSrc) Start(self *base.GstBaseSrc) bool {
 
 	m.state.mux.Lock()
 
-	if strings.HasPrefix(m.settings.accessKeyID, "env:") {
-		spl := strings.Split(m.settings.accessKeyID, "env:")
-		m.settings.accessKeyID = os.Getenv(spl[len(spl)-1])
-	}
-
-	if strings.HasPrefix(m.settings.secretAccessKey, "env:") {
-		spl := strings.Split(m.settings.secretAccessKey, "env:")
-		m.settings.secretAccessKey = os.Getenv(spl[len(spl)-1])
-	}
+	// The properties keep any env: references, so the resolved secrets are never readable
+	settings := m.settings.resolved()
 
-	client, err := getMinIOClient(m.settings)
+	client, err := getMinIOClient(settings)
 	if err != nil {
 		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorFailed,
 			fmt.Sprintf("Failed to connect to MinIO endpoint %s", m.settings.endpoint), err.Error())
@@ -120,11 +127,34 @@ func (m *minioSrc) Start(self *base.GstBaseSrc) bool {
 		return false
 	}
 
-	self.Log(srcCAT, gst.LevelInfo, fmt.Sprintf("Requesting %s/%s from %s", m.settings.bucket, m.settings.key, m.settings.endpoint))
-	m.state.object, err = client.GetObject(context.Background(), m.settings.bucket, m.settings.key, minio.GetObjectOptions{})
+	sse, err := settings.serverSideEncryption()
+	if err != nil {
+		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorSettings, err.Error(), "")
+		m.state.mux.Unlock()
+		return false
+	}
+
+	objectName := fmt.Sprintf("%s/%s", m.settings.bucket, m.settings.key)
+	if m.settings.versionID != "" {
+		objectName = fmt.Sprintf("%s (version %s)", objectName, m.settings.versionID)
+	}
+
+	self.Log(srcCAT, gst.LevelInfo, fmt.Sprintf("Requesting %s from %s", objectName, m.settings.endpoint))
+	opts := minio.GetObjectOptions{
+		ServerSideEncryption: s3io.CustomerKeyEncryption(sse),
+		VersionID:            m.settings.versionID,
+	}
+	if etag := strings.Trim(m.settings.etag, `"`); etag != "" {
+		if err := opts.SetMatchETag(etag); err != nil {
+			self.ErrorMessage(gst.DomainResource, gst.ResourceErrorSettings, err.Error(), "")
+			m.state.mux.Unlock()
+			return false
+		}
+	}
+	m.state.object, err = client.GetObject(context.Background(), m.settings.bucket, m.settings.key, opts)
 	if err != nil {
 		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorOpenRead,
-			fmt.Sprintf("Failed to retrieve object %q from bucket %q", m.settings.key, m.settings.bucket), err.Error())
+			fmt.Sprintf("Failed to retrieve object %s", objectName), err.Error())
 		m.state.mux.Unlock()
 		return false
 	}
@@ -132,13 +162,29 @@ func (m *minioSrc) Start(self *base.GstBaseSrc) bool {
 	self.Log(srcCAT, gst.LevelInfo, "Getting HEAD for object")
 	m.state.objInfo, err = m.state.object.Stat()
 	if err != nil {
-		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorOpenRead,
-			fmt.Sprintf("Failed to stat object %q in bucket %q: %s", m.settings.key, m.settings.bucket, err.Error()), "")
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchKey", "NoSuchVersion":
+			self.ErrorMessage(gst.DomainResource, gst.ResourceErrorNotFound,
+				fmt.Sprintf("Object %s does not exist or has been deleted", objectName), err.Error())
+		case "PreconditionFailed":
+			self.ErrorMessage(gst.DomainResource, gst.ResourceErrorOpenRead,
+				fmt.Sprintf("Object %s has been overwritten since the job was created, it no longer has ETag %s", objectName, m.settings.etag), err.Error())
+		default:
+			self.ErrorMessage(gst.DomainResource, gst.ResourceErrorOpenRead,
+				fmt.Sprintf("Failed to stat object %s: %s", objectName, err.Error()), "")
+		}
 		m.state.mux.Unlock()
 		return false
 	}
 	self.Log(srcCAT, gst.LevelInfo, fmt.Sprintf("%+v", m.state.objInfo))
 
+	if m.settings.readAheadBufferSize > 0 {
+		self.Log(srcCAT, gst.LevelInfo, fmt.Sprintf("Reading ahead up to %d bytes in chunks of %d bytes", m.settings.readAheadBufferSize, m.settings.readAheadChunkSize))
+		m.state.readAhead = s3io.NewReadAhead(m.state.object, m.state.objInfo.Size,
+			int64(m.settings.readAheadChunkSize), int64(m.settings.readAheadBufferSize),
+			&m.state.bytesFetched, categoryLogger(srcCAT))
+	}
+
 	m.state.started = true
 	m.state.mux.Unlock()
 
@@ -158,6 +204,13 @@ func (m *minioSrc) Stop(self *base.GstBaseSrc) bool {
 		return false
 	}
 
+	if m.state.readAhead != nil {
+		m.state.readAhead.Close()
+		m.state.readAhead = nil
+	}
+	self.Log(srcCAT, gst.LevelInfo, fmt.Sprintf("Fetched %d bytes from MinIO and pushed %d bytes downstream",
+		atomic.LoadUint64(&m.state.bytesFetched), atomic.LoadUint64(&m.state.bytesRead)))
+
 	if err := m.state.object.Close(); err != nil {
 		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorClose, "Failed to close the bucket object", err.Error())
 		return false
@@ -182,13 +235,22 @@ func (m *minioSrc) Fill(self *base.GstBaseSrc, offset uint64, size uint, buffer
 	m.state.mux.Lock()
 	defer m.state.mux.Unlock()
 
+	var reader io.ReaderAt = m.state.object
+	if m.state.readAhead != nil {
+		reader = m.state.readAhead
+	}
+
 	data := make([]byte, size)
-	read, err := m.state.object.ReadAt(data, int64(offset))
+	read, err := reader.ReadAt(data, int64(offset))
 	if err != nil && err != io.EOF {
 		self.ErrorMessage(gst.DomainResource, gst.ResourceErrorRead,
 			fmt.Sprintf("Failed to read %d bytes from object at offset %d", size, offset), err.Error())
 		return gst.FlowError
 	}
+	atomic.AddUint64(&m.state.bytesRead, uint64(read))
+	if m.state.readAhead == nil {
+		atomic.AddUint64(&m.state.bytesFetched, uint64(read))
+	}
 
 	if read < int(size) {
 		self.Log(srcCAT, gst.LevelDebug, fmt.Sprintf("Only read %d bytes from object, trimming", read))
@@ -9,9 +9,16 @@ import (
 // Even though there is overlap in properties, they have to be declared twice.
 // This is because the GType system doesn't allow for GObjects to share pointers
 // to the exact same GParamSpecs.
+//
+// Credentials are write-only, so they never appear in dot graphs of the pipeline.
 
 const defaultPartSize = 1024 * 1024 * 10
 const minPartSize = 1024 * 1024 * 5
+const defaultUploadConcurrency = 4
+const defaultUploadBufferSize = 1024 * 1024 * 64
+const defaultReadAheadChunkSize = 1024 * 1024 * 2
+const minReadAheadChunkSize = 1024 * 64
+const defaultReadAheadBufferSize = 1024 * 1024 * 16
 
 var sinkProperties = []*glib.ParamSpec{
 	glib.NewStringParam(
@@ -63,19 +70,26 @@ var sinkProperties = []*glib.ParamSpec{
 		nil,
 		glib.ParameterReadWrite,
 	),
+	glib.NewStringParam(
+		"location",
+		"Object location",
+		"An alias for the key property, used by elements that write to sequential locations such as splitmuxsink",
+		nil,
+		glib.ParameterReadWrite,
+	),
 	glib.NewStringParam(
 		"access-key-id",
 		"Access Key ID",
-		"The access key ID to use for authentication",
+		"The access key ID to use for authentication. Use env: prefix to denote an environment variable.",
 		nil,
-		glib.ParameterReadWrite,
+		glib.ParameterWritable,
 	),
 	glib.NewStringParam(
 		"secret-access-key",
 		"Secret Access Key",
-		"The secret access key to use for authentication",
+		"The secret access key to use for authentication. Use env: prefix to denote an environment variable.",
 		nil,
-		glib.ParameterReadWrite,
+		glib.ParameterWritable,
 	),
 	glib.NewUint64Param(
 		"part-size",
@@ -84,6 +98,90 @@ var sinkProperties = []*glib.ParamSpec{
 		minPartSize, math.MaxInt64, defaultPartSize,
 		glib.ParameterReadWrite,
 	),
+	glib.NewStringParam(
+		"content-type",
+		"Content Type",
+		"The content type to store the object with. Derived from the negotiated caps when empty.",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"content-disposition",
+		"Content Disposition",
+		"The Content-Disposition header to store the object with",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"cache-control",
+		"Cache Control",
+		"The Cache-Control header to store the object with",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"metadata",
+		"User Metadata",
+		"User metadata to store the object with, as URL-encoded key=value pairs separated by &",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"tags",
+		"Object Tags",
+		"Tags to apply to the object, as URL-encoded key=value pairs separated by &",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"storage-class",
+		"Storage Class",
+		"The storage class to store the object in",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"sse-type",
+		"Server-Side Encryption",
+		"The server-side encryption to store the object with, one of SSE-S3, SSE-KMS or SSE-C",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"sse-kms-key-id",
+		"SSE-KMS Key ID",
+		"The ID of the KMS key to encrypt the object with when sse-type is SSE-KMS",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"sse-customer-key",
+		"SSE-C Customer Key",
+		"The base64-encoded 256-bit key to encrypt the object with when sse-type is SSE-C. Use env: prefix to denote an environment variable.",
+		nil,
+		glib.ParameterWritable,
+	),
+	glib.NewUintParam(
+		"upload-concurrency",
+		"Upload Concurrency",
+		"The number of parts uploaded at the same time",
+		1, 64, defaultUploadConcurrency,
+		glib.ParameterReadWrite,
+	),
+	glib.NewUint64Param(
+		"upload-buffer-size",
+		"Upload Buffer Size",
+		"The maximum number of bytes held in memory for parts waiting on or in the middle of upload. Rendering blocks while the buffer is full.",
+		0, math.MaxInt64, defaultUploadBufferSize,
+		glib.ParameterReadWrite,
+	),
+	glib.NewUint64Param(
+		"bytes-uploaded",
+		"Bytes Uploaded",
+		"The number of bytes uploaded to MinIO so far",
+		0, math.MaxUint64, 0,
+		glib.ParameterReadable,
+	),
 }
 
 var srcProperties = []*glib.ParamSpec{
@@ -136,18 +234,67 @@ var srcProperties = []*glib.ParamSpec{
 		nil,
 		glib.ParameterReadWrite,
 	),
+	glib.NewStringParam(
+		"version-id",
+		"Object version ID",
+		"The version of the object to read on a versioned bucket. The latest version is read when empty.",
+		nil,
+		glib.ParameterReadWrite,
+	),
+	glib.NewStringParam(
+		"etag",
+		"Object ETag",
+		"The ETag the object must have. Reading fails if the object has been overwritten since.",
+		nil,
+		glib.ParameterReadWrite,
+	),
 	glib.NewStringParam(
 		"access-key-id",
 		"Access Key ID",
 		"The access key ID to use for authentication. Use env: prefix to denote an environment variable.",
 		nil,
-		glib.ParameterReadWrite,
+		glib.ParameterWritable,
 	),
 	glib.NewStringParam(
 		"secret-access-key",
 		"Secret Access Key",
 		"The secret access key to use for authentication. Use env: prefix to denote an environment variable.",
 		nil,
+		glib.ParameterWritable,
+	),
+	glib.NewStringParam(
+		"sse-customer-key",
+		"SSE-C Customer Key",
+		"The base64-encoded 256-bit key the object was encrypted with, if it is stored with SSE-C. Use env: prefix to denote an environment variable.",
+		nil,
+		glib.ParameterWritable,
+	),
+	glib.NewUint64Param(
+		"read-ahead-chunk-size",
+		"Read-Ahead Chunk Size",
+		"The size of each range request made to read ahead of the pipeline",
+		minReadAheadChunkSize, math.MaxInt64, defaultReadAheadChunkSize,
+		glib.ParameterReadWrite,
+	),
+	glib.NewUint64Param(
+		"read-ahead-buffer-size",
+		"Read-Ahead Buffer Size",
+		"The maximum number of bytes read ahead of the pipeline and held in memory. Set to 0 to read only what is requested.",
+		0, math.MaxInt64, defaultReadAheadBufferSize,
 		glib.ParameterReadWrite,
 	),
+	glib.NewUint64Param(
+		"bytes-read",
+		"Bytes Read",
+		"The number of bytes read from the object and pushed downstream so far",
+		0, math.MaxUint64, 0,
+		glib.ParameterReadable,
+	),
+	glib.NewUint64Param(
+		"bytes-fetched",
+		"Bytes Fetched",
+		"The number of bytes fetched from MinIO so far, including read-ahead discarded on seeks",
+		0, math.MaxUint64, 0,
+		glib.ParameterReadable,
+	),
 }
@@ -0,0 +1,20 @@
+// Package s3io implements the buffered readers and writers the MinIO elements use to stream objects.
+// It does not depend on GStreamer, messages are passed to a Logger supplied by the elements.
+package s3io
+
+// Level is the verbosity of a log message, in the order of the GStreamer debug levels.
+type Level int
+
+// Log levels used by this package
+const (
+	LevelInfo Level = iota
+	LevelDebug
+	LevelLog
+	LevelTrace
+)
+
+// Logger receives the log messages of the readers and writers in this package.
+type Logger func(level Level, msg string)
+
+// discard is the Logger used when none is given.
+func discard(Level, string) {}
@@ -0,0 +1,179 @@
+package s3io
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ReadAhead serves reads of an object from chunks fetched ahead of time in the background. Chunks are
+// fetched in order from the position last read, each with a single range request, until the buffer is
+// full. A read outside of the chunks in the buffer is treated as a seek: the buffer is dropped and
+// fetching restarts from the new position.
+type ReadAhead struct {
+	object    io.ReaderAt
+	size      int64
+	chunkSize int64
+	maxChunks int64
+	// A counter of the bytes fetched, owned by the caller
+	fetched *uint64
+	log     Logger
+
+	// guards the fields below, which are shared with the fetcher
+	mu   sync.Mutex
+	cond *sync.Cond
+	// A map of fetched chunks to their content
+	chunks map[int64][]byte
+	// The first chunk kept in the buffer and the next chunk to fetch
+	first, next int64
+	// Incremented on every seek, so chunks fetched for an earlier position are discarded
+	generation int
+	// The error returned fetching the next chunk
+	fetchErr error
+	closed   bool
+}
+
+// NewReadAhead returns a ReadAhead for an object of the given size, read in chunks of chunkSize up to
+// bufferSize bytes ahead. Fetching starts from the beginning of the object right away.
+func NewReadAhead(object io.ReaderAt, size, chunkSize, bufferSize int64, fetched *uint64, logger Logger) *ReadAhead {
+	maxChunks := bufferSize / chunkSize
+	if maxChunks < 1 {
+		maxChunks = 1
+	}
+	if logger == nil {
+		logger = discard
+	}
+	if fetched == nil {
+		fetched = new(uint64)
+	}
+	r := &ReadAhead{
+		object:    object,
+		size:      size,
+		chunkSize: chunkSize,
+		maxChunks: maxChunks,
+		fetched:   fetched,
+		log:       logger,
+		chunks:    make(map[int64][]byte),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	go r.fetch()
+	return r
+}
+
+// ReadAt reads len(p) bytes from the object at the given offset, waiting for the chunks covering them
+// to be fetched. It returns io.EOF if the object ends before p is filled.
+func (r *ReadAhead) ReadAt(p []byte, off int64) (int, error) {
+	var read int
+	for read < len(p) {
+		pos := off + int64(read)
+		if pos >= r.size {
+			return read, io.EOF
+		}
+		data, err := r.chunk(pos / r.chunkSize)
+		if err != nil {
+			return read, err
+		}
+		read += copy(p[read:], data[pos%r.chunkSize:])
+	}
+	return read, nil
+}
+
+// Close stops fetching chunks. A fetch in progress is discarded when it returns.
+func (r *ReadAhead) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.chunks = nil
+	r.cond.Broadcast()
+}
+
+// chunk returns the given chunk once it has been fetched, dropping the chunks before it from the buffer
+// so the fetcher can move ahead.
+func (r *ReadAhead) chunk(idx int64) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if idx < r.first || idx >= r.first+r.maxChunks {
+		r.log(LevelDebug, fmt.Sprintf("Chunk %d is outside of the read-ahead buffer, fetching from it", idx))
+		r.chunks = make(map[int64][]byte)
+		r.first, r.next = idx, idx
+		r.generation++
+		r.fetchErr = nil
+	} else {
+		for ; r.first < idx; r.first++ {
+			delete(r.chunks, r.first)
+		}
+	}
+	r.cond.Broadcast()
+
+	for {
+		if r.closed {
+			return nil, errors.New("The read-ahead buffer is closed")
+		}
+		if data, ok := r.chunks[idx]; ok {
+			return data, nil
+		}
+		if r.fetchErr != nil {
+			return nil, r.fetchErr
+		}
+		r.cond.Wait()
+	}
+}
+
+// fetch fetches chunks in the background while there is room in the buffer.
+func (r *ReadAhead) fetch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		for !r.closed && (r.fetchErr != nil || r.next >= r.first+r.maxChunks || r.next*r.chunkSize >= r.size) {
+			r.cond.Wait()
+		}
+		if r.closed {
+			return
+		}
+		// The reader may have moved past chunks that were not fetched yet
+		if r.next < r.first {
+			r.next = r.first
+		}
+
+		idx, generation := r.next, r.generation
+		r.mu.Unlock()
+		data, err := r.fetchChunk(idx)
+		r.mu.Lock()
+
+		if r.closed || generation != r.generation {
+			r.log(LevelDebug, fmt.Sprintf("Discarding chunk %d fetched before a seek", idx))
+			continue
+		}
+		if idx < r.first {
+			r.log(LevelDebug, fmt.Sprintf("Discarding chunk %d, the reader has moved past it", idx))
+			continue
+		}
+		if err != nil {
+			r.fetchErr = err
+		} else {
+			r.chunks[idx] = data
+			r.next++
+		}
+		r.cond.Broadcast()
+	}
+}
+
+// fetchChunk reads the given chunk from the object with a single range request.
+func (r *ReadAhead) fetchChunk(idx int64) ([]byte, error) {
+	off := idx * r.chunkSize
+	size := r.chunkSize
+	if off+size > r.size {
+		size = r.size - off
+	}
+	r.log(LevelLog, fmt.Sprintf("Fetching chunk %d of %d bytes at offset %d", idx, size, off))
+	data := make([]byte, size)
+	read, err := r.object.ReadAt(data, off)
+	atomic.AddUint64(r.fetched, uint64(read))
+	if err != nil && !(err == io.EOF && int64(read) == size) {
+		return nil, err
+	}
+	return data, nil
+}
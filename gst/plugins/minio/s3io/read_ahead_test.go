@@ -0,0 +1,167 @@
+package s3io
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeObject is an io.ReaderAt over an object in memory that records the offset of every read.
+type fakeObject struct {
+	data []byte
+	// if set, receives the offset of every read as it starts
+	started chan int64
+	// reads at the offsets in blocked wait for the channel to be closed
+	blocked map[int64]chan struct{}
+
+	mu      sync.Mutex
+	offsets []int64
+}
+
+func (f *fakeObject) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	f.offsets = append(f.offsets, off)
+	f.mu.Unlock()
+	if f.started != nil {
+		f.started <- off
+	}
+	if release, ok := f.blocked[off]; ok {
+		<-release
+	}
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	// Like a minio.Object, the end of the object is reported even when p is filled
+	if off+int64(n) == int64(len(f.data)) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// reads returns the number of reads made at the given offset.
+func (f *fakeObject) reads(off int64) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var count int
+	for _, o := range f.offsets {
+		if o == off {
+			count++
+		}
+	}
+	return count
+}
+
+func newTestReadAhead(object *fakeObject) *ReadAhead {
+	var fetched uint64
+	// Chunks of 4 bytes, 2 chunks ahead
+	return NewReadAhead(object, int64(len(object.data)), 4, 8, &fetched, nil)
+}
+
+func expectRead(t *testing.T, r *ReadAhead, off int64, size int, expected string, expectedErr error) {
+	t.Helper()
+	p := make([]byte, size)
+	n, err := r.ReadAt(p, off)
+	if err != expectedErr {
+		t.Fatalf("Expected error %v reading %d bytes at %d, got %v", expectedErr, size, off, err)
+	}
+	if string(p[:n]) != expected {
+		t.Errorf("Expected %q reading %d bytes at %d, got %q", expected, size, off, p[:n])
+	}
+}
+
+func TestReadAheadBackwardSeekInvalidatesBuffer(t *testing.T) {
+	object := &fakeObject{data: []byte("0123456789abcdefghijklmnopqrstuv")}
+	r := newTestReadAhead(object)
+	defer r.Close()
+
+	expectRead(t, r, 0, 4, "0123", nil)
+	// Moving on to the second chunk drops the first from the buffer
+	expectRead(t, r, 4, 4, "4567", nil)
+	expectRead(t, r, 0, 6, "012345", nil)
+
+	if reads := object.reads(0); reads != 2 {
+		t.Errorf("Expected the first chunk to be fetched twice, got %d fetches", reads)
+	}
+	expectRead(t, r, 8, 8, "89abcdef", nil)
+}
+
+func TestReadAheadDiscardsChunksReadPast(t *testing.T) {
+	release := make(chan struct{})
+	object := &fakeObject{
+		data:    []byte("0123456789abcdefghijklmnopqrstuv"),
+		started: make(chan int64, 64),
+		blocked: map[int64]chan struct{}{4: release},
+	}
+	var fetched uint64
+	// Chunks of 4 bytes, 4 chunks ahead
+	r := NewReadAhead(object, int64(len(object.data)), 4, 16, &fetched, nil)
+	defer r.Close()
+
+	expectRead(t, r, 0, 4, "0123", nil)
+	// Wait for the fetch of the second chunk to start
+	for off := range object.started {
+		if off == 4 {
+			break
+		}
+	}
+
+	// Reading the third chunk moves the buffer past the second while it is being fetched
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		expectRead(t, r, 8, 4, "89ab", nil)
+	}()
+	waitFirst(t, r, 2)
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the third chunk")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.chunks[1]; ok {
+		t.Error("Expected the second chunk to be discarded after the reader moved past it")
+	}
+}
+
+// waitFirst waits for the first chunk in the buffer of r to become idx.
+func waitFirst(t *testing.T, r *ReadAhead, idx int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mu.Lock()
+		first := r.first
+		r.mu.Unlock()
+		if first == idx {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the buffer to move to chunk %d", idx)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReadAheadShortLastChunk(t *testing.T) {
+	object := &fakeObject{data: []byte("0123456789")}
+	r := newTestReadAhead(object)
+	defer r.Close()
+
+	expectRead(t, r, 6, 4, "6789", nil)
+	expectRead(t, r, 8, 4, "89", io.EOF)
+	expectRead(t, r, 10, 4, "", io.EOF)
+	expectRead(t, r, 0, 16, "0123456789", io.EOF)
+}
+
+func TestReadAheadClosed(t *testing.T) {
+	r := newTestReadAhead(&fakeObject{data: []byte("0123456789")})
+	r.Close()
+
+	if _, err := r.ReadAt(make([]byte, 4), 0); err == nil {
+		t.Error("Expected an error reading from a closed buffer")
+	}
+}
@@ -0,0 +1,397 @@
+package s3io
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// maxParts is the maximum number of parts in an S3 multipart upload.
+const maxParts = 10000
+
+// MultipartClient is the part of the minio.Core API used to write objects with a multipart upload.
+// Empty objects are written with a single PutObject, since a multipart upload needs at least one part.
+type MultipartClient interface {
+	PutObject(ctx context.Context, bucket, object string, data io.Reader, size int64, md5Base64, sha256Hex string, opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	NewMultipartUpload(ctx context.Context, bucket, object string, opts minio.PutObjectOptions) (string, error)
+	PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data io.Reader, size int64, md5Base64, sha256Hex string, sse encrypt.ServerSide) (minio.ObjectPart, error)
+	CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, parts []minio.CompletePart) (string, error)
+	AbortMultipartUpload(ctx context.Context, bucket, object, uploadID string) error
+}
+
+// SeekWriterConfig configures a SeekWriter.
+type SeekWriterConfig struct {
+	Bucket, Key string
+	// The size of each part to upload
+	PartSize int64
+	// The number of parts uploaded at a time
+	UploadConcurrency int
+	// The number of bytes waiting on or in the middle of upload before writes block
+	UploadBufferSize int64
+	// The options the object is stored with
+	Options minio.PutObjectOptions
+	// A counter of the bytes uploaded, updated atomically, may be nil
+	Uploaded *uint64
+	// Receives the log messages of the writer, may be nil
+	Logger Logger
+}
+
+// SeekWriter writes an object with the S3 multipart upload API, so only the complete object ever
+// becomes visible in the bucket. Parts are uploaded as they fill up, except for those that muxers
+// commonly seek back to: the first part, where headers are rewritten when the stream ends, and the
+// part before the one currently being written. Those are kept in memory until the upload is completed.
+//
+// Uploads run in the background, a number of them at a time. Writes block while the parts waiting on
+// or in the middle of upload exceed the upload buffer, which applies backpressure to the pipeline.
+// Seeking into a part that is still in flight takes a copy of it back into memory, and it is uploaded
+// again once the earlier upload of the part has finished.
+type SeekWriter struct {
+	// The current position in the buffer
+	currentPosition int64
+	// The size of each part to upload
+	partSize int64
+	// A map of in memory parts to their content
+	parts map[int64][]byte
+	// The ID of the multipart upload, empty until the first part is uploaded
+	uploadID string
+	// The client the parts are uploaded with
+	client      MultipartClient
+	bucket, key string
+	// The options the object is stored with
+	opts minio.PutObjectOptions
+	// The content type used when none is set in opts
+	defaultContentType string
+	// A counter of the bytes uploaded, owned by the caller
+	uploaded *uint64
+	log      Logger
+
+	// Limits on the uploads running in the background
+	uploadSlots      chan struct{}
+	uploadBufferSize int64
+	// Cancels the uploads in flight when the writer is aborted
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// guards the fields below, which are shared with the uploads in flight
+	mu   sync.Mutex
+	cond *sync.Cond
+	// A map of uploaded parts to the ETag returned for them
+	uploadedParts map[int64]string
+	// A map of parts waiting on or in the middle of upload to their content
+	inflight map[int64][]byte
+	// The number of bytes in inflight
+	buffered int64
+	// The first error returned by an upload
+	uploadErr error
+}
+
+// NewSeekWriter returns a SeekWriter for the object in the given config. The multipart upload is
+// started with the first part uploaded.
+func NewSeekWriter(client MultipartClient, cfg SeekWriterConfig) *SeekWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+	if cfg.Logger == nil {
+		cfg.Logger = discard
+	}
+	if cfg.Uploaded == nil {
+		cfg.Uploaded = new(uint64)
+	}
+	s := &SeekWriter{
+		currentPosition:  0,
+		partSize:         cfg.PartSize,
+		parts:            make(map[int64][]byte),
+		client:           client,
+		bucket:           cfg.Bucket,
+		key:              cfg.Key,
+		opts:             cfg.Options,
+		uploaded:         cfg.Uploaded,
+		log:              cfg.Logger,
+		uploadSlots:      make(chan struct{}, cfg.UploadConcurrency),
+		uploadBufferSize: cfg.UploadBufferSize,
+		ctx:              ctx,
+		cancel:           cancel,
+		uploadedParts:    make(map[int64]string),
+		inflight:         make(map[int64][]byte),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *SeekWriter) Write(p []byte) (int, error) {
+	if err := s.err(); err != nil {
+		return 0, err
+	}
+	s.log(LevelTrace, fmt.Sprintf("Adding %d bytes to the buffer at position %d", len(p), s.currentPosition))
+	wrote, err := s.buffer(0, p)
+	if err != nil {
+		return wrote, err
+	}
+	s.log(LevelTrace, fmt.Sprintf("Wrote %d bytes to buffer, flushing complete parts", wrote))
+	return wrote, s.flush(false)
+}
+
+func (s *SeekWriter) Seek(offset int64, whence int) (int64, error) {
+	// Only needs to support SeekStart
+	s.log(LevelDebug, fmt.Sprintf("Setting current position to %d", offset))
+	s.currentPosition = offset
+	return s.currentPosition, nil
+}
+
+// Position returns the position the next write starts at.
+func (s *SeekWriter) Position() int64 {
+	return s.currentPosition
+}
+
+// SetDefaultContentType sets the content type to store the object with if none was configured. It has
+// no effect once the upload has started.
+func (s *SeekWriter) SetDefaultContentType(contentType string) {
+	if s.uploadID != "" {
+		return
+	}
+	s.defaultContentType = contentType
+}
+
+// Close uploads the parts remaining in memory, waits for all uploads to finish, and completes the
+// multipart upload. An empty object is stored if nothing was written.
+func (s *SeekWriter) Close() error {
+	s.log(LevelInfo, "Flushing remaining buffers to MinIO")
+	if err := s.flush(true); err != nil {
+		return err
+	}
+	s.wait()
+	if err := s.err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.uploadedParts) == 0 {
+		s.log(LevelInfo, fmt.Sprintf("No data was written, storing empty object %s/%s", s.bucket, s.key))
+		_, err := s.client.PutObject(context.Background(), s.bucket, s.key, bytes.NewReader(nil), 0, "", "", s.putOptions())
+		return err
+	}
+
+	parts := make([]minio.CompletePart, 0, len(s.uploadedParts))
+	for part, etag := range s.uploadedParts {
+		parts = append(parts, minio.CompletePart{PartNumber: int(part + 1), ETag: etag})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	s.log(LevelInfo, fmt.Sprintf("Completing upload of %d parts to final object %s/%s", len(parts), s.bucket, s.key))
+	if _, err := s.client.CompleteMultipartUpload(context.Background(), s.bucket, s.key, s.uploadID, parts); err != nil {
+		return err
+	}
+	s.uploadID = ""
+	s.uploadedParts = make(map[int64]string)
+	return nil
+}
+
+// Abort cancels the uploads in flight and the multipart upload, discarding any parts uploaded for it.
+func (s *SeekWriter) Abort() error {
+	s.cancel()
+	s.wait()
+	if s.uploadID == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log(LevelInfo, fmt.Sprintf("Aborting upload of %d parts for %s/%s", len(s.uploadedParts), s.bucket, s.key))
+	if err := s.client.AbortMultipartUpload(context.Background(), s.bucket, s.key, s.uploadID); err != nil {
+		return err
+	}
+	s.uploadID = ""
+	s.uploadedParts = make(map[int64]string)
+	return nil
+}
+
+func (s *SeekWriter) buffer(from int, p []byte) (int, error) {
+	currentPart := s.currentPosition / s.partSize
+	writeat := s.currentPosition % s.partSize
+	lenToWrite := int64(len(p))
+
+	s.log(LevelLog, fmt.Sprintf("Buffering %d bytes to part %d at offset %d", lenToWrite, currentPart, writeat))
+
+	var buf []byte
+	var ok bool
+	if buf, ok = s.parts[currentPart]; !ok {
+		var err error
+		if buf, err = s.reclaimPart(currentPart); err != nil {
+			return from, err
+		}
+		if buf == nil {
+			size := writeat + lenToWrite
+			s.log(LevelTrace, fmt.Sprintf("Allocating new buffer with size %d for part %d", size, currentPart))
+			buf = make([]byte, size)
+		}
+		s.parts[currentPart] = buf
+	}
+
+	if lenToWrite+writeat > s.partSize {
+		s.log(LevelTrace, fmt.Sprintf("Resizing part %d buffer to %d", currentPart, s.partSize))
+		newbuf := make([]byte, s.partSize)
+		copy(newbuf, buf)
+		s.parts[currentPart] = newbuf
+		buf = newbuf
+	} else if lenToWrite+writeat > int64(len(buf)) {
+		size := lenToWrite + writeat
+		s.log(LevelTrace, fmt.Sprintf("Resizing part %d buffer to %d", currentPart, size))
+		newbuf := make([]byte, size)
+		copy(newbuf, buf)
+		s.parts[currentPart] = newbuf
+		buf = newbuf
+	}
+
+	wrote := copy(buf[writeat:], p)
+
+	s.currentPosition += int64(wrote)
+
+	if int64(wrote) != lenToWrite {
+		s.log(LevelLog, fmt.Sprintf("Only wrote %d, continuing to next part", wrote))
+		return s.buffer(from+wrote, p[wrote:])
+	}
+
+	return from + wrote, nil
+}
+
+// reclaimPart returns a copy of the given part if it is still in flight, so it can be rewritten, or nil
+// if the part has not been written yet. The contents of parts that have finished uploading cannot be
+// read back until the upload is completed.
+func (s *SeekWriter) reclaimPart(part int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data, ok := s.inflight[part]; ok {
+		s.log(LevelDebug, fmt.Sprintf("Reclaiming part %d from upload for rewriting", part))
+		buf := make([]byte, len(data))
+		copy(buf, data)
+		return buf, nil
+	}
+	if _, ok := s.uploadedParts[part]; ok {
+		return nil, fmt.Errorf("Cannot rewrite position %d, part %d has already been uploaded", s.currentPosition, part)
+	}
+	if part >= maxParts {
+		return nil, fmt.Errorf("The object exceeds the maximum of %d parts, increase the part-size", maxParts)
+	}
+	return nil, nil
+}
+
+// flush starts uploads for the parts that are full and no longer likely to be rewritten, or every
+// part in memory when all is true.
+func (s *SeekWriter) flush(all bool) error {
+	currentPart := s.currentPosition / s.partSize
+	for part, buf := range s.parts {
+		if !all {
+			if int64(len(buf)) != s.partSize || part == 0 || part >= currentPart-1 {
+				continue
+			}
+		}
+		if err := s.uploadPart(part, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadPart hands the given part to a background upload. It blocks while an earlier upload of the
+// same part is in flight, so the last contents written are the ones that end up in the object, and
+// while the upload buffer is full.
+func (s *SeekWriter) uploadPart(part int64, data []byte) error {
+	if s.uploadID == "" {
+		s.log(LevelInfo, fmt.Sprintf("Starting multipart upload to %s/%s", s.bucket, s.key))
+		uploadID, err := s.client.NewMultipartUpload(s.ctx, s.bucket, s.key, s.putOptions())
+		if err != nil {
+			return err
+		}
+		s.uploadID = uploadID
+	}
+
+	size := int64(len(data))
+	s.mu.Lock()
+	for s.uploadErr == nil {
+		_, busy := s.inflight[part]
+		// A single part is always let through, so parts larger than the buffer cannot block forever
+		full := len(s.inflight) > 0 && s.buffered+size > s.uploadBufferSize
+		if !busy && !full {
+			break
+		}
+		if full {
+			s.log(LevelDebug, fmt.Sprintf("Upload buffer is full, waiting to upload part %d", part+1))
+		}
+		s.cond.Wait()
+	}
+	if s.uploadErr != nil {
+		s.mu.Unlock()
+		return s.uploadErr
+	}
+	s.inflight[part] = data
+	s.buffered += size
+	s.mu.Unlock()
+	delete(s.parts, part)
+
+	go func() {
+		s.uploadSlots <- struct{}{}
+		defer func() { <-s.uploadSlots }()
+
+		s.log(LevelInfo, fmt.Sprintf("Uploading part %d of %s/%s", part+1, s.bucket, s.key))
+		objPart, err := s.client.PutObjectPart(s.ctx,
+			s.bucket, s.key, s.uploadID, int(part+1),
+			bytes.NewReader(data), size,
+			"", "", CustomerKeyEncryption(s.opts.ServerSideEncryption),
+		)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.inflight, part)
+		s.buffered -= size
+		if err != nil {
+			if s.uploadErr == nil {
+				s.uploadErr = err
+			}
+		} else {
+			atomic.AddUint64(s.uploaded, uint64(size))
+			s.uploadedParts[part] = objPart.ETag
+		}
+		s.cond.Broadcast()
+	}()
+	return nil
+}
+
+// putOptions returns the options the object is stored with, falling back to the default content type.
+func (s *SeekWriter) putOptions() minio.PutObjectOptions {
+	opts := s.opts
+	if opts.ContentType == "" {
+		opts.ContentType = s.defaultContentType
+	}
+	return opts
+}
+
+// wait blocks until there are no uploads in flight.
+func (s *SeekWriter) wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.inflight) > 0 {
+		s.cond.Wait()
+	}
+}
+
+// err returns the first error returned by an upload.
+func (s *SeekWriter) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.uploadErr
+}
+
+// CustomerKeyEncryption returns the given encryption if it uses a customer key. Only SSE-C headers
+// are sent with part uploads and reads, the server rejects them for the other types.
+func CustomerKeyEncryption(sse encrypt.ServerSide) encrypt.ServerSide {
+	if sse != nil && sse.Type() == encrypt.SSEC {
+		return sse
+	}
+	return nil
+}
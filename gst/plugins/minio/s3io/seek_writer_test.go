@@ -0,0 +1,273 @@
+package s3io
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+var errUpload = errors.New("upload failed")
+
+// fakeCore is a MultipartClient that keeps the parts of a single upload in memory.
+type fakeCore struct {
+	// receives the number of each part as its upload starts
+	started chan int
+	// if set, uploads block until it is closed
+	release chan struct{}
+	// the part number to fail uploading, if any
+	failPart int
+
+	mu        sync.Mutex
+	parts     map[int][]byte
+	etags     map[int]string
+	uploads   int
+	completed []byte
+	aborted   bool
+	// the options and content of an object stored with PutObject, if any
+	putOpts *minio.PutObjectOptions
+	put     []byte
+}
+
+func newFakeCore() *fakeCore {
+	return &fakeCore{
+		started: make(chan int, 64),
+		parts:   make(map[int][]byte),
+		etags:   make(map[int]string),
+	}
+}
+
+func (f *fakeCore) PutObject(ctx context.Context, bucket, object string, data io.Reader, size int64, md5Base64, sha256Hex string, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	body, err := ioutil.ReadAll(data)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.putOpts = &opts
+	f.put = body
+	return minio.UploadInfo{Bucket: bucket, Key: object, Size: int64(len(body))}, nil
+}
+
+func (f *fakeCore) NewMultipartUpload(ctx context.Context, bucket, object string, opts minio.PutObjectOptions) (string, error) {
+	return "upload", nil
+}
+
+func (f *fakeCore) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data io.Reader, size int64, md5Base64, sha256Hex string, sse encrypt.ServerSide) (minio.ObjectPart, error) {
+	body, err := ioutil.ReadAll(data)
+	if err != nil {
+		return minio.ObjectPart{}, err
+	}
+	f.started <- partID
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return minio.ObjectPart{}, ctx.Err()
+		}
+	}
+	if partID == f.failPart {
+		return minio.ObjectPart{}, errUpload
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads++
+	etag := fmt.Sprintf("%d-%d", partID, f.uploads)
+	f.parts[partID] = body
+	f.etags[partID] = etag
+	return minio.ObjectPart{PartNumber: partID, ETag: etag, Size: int64(len(body))}, nil
+}
+
+func (f *fakeCore) CompleteMultipartUpload(ctx context.Context, bucket, object, uploadID string, parts []minio.CompletePart) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var data []byte
+	for i, part := range parts {
+		if part.PartNumber != i+1 {
+			return "", fmt.Errorf("Part %d is missing", i+1)
+		}
+		if part.ETag != f.etags[part.PartNumber] {
+			return "", fmt.Errorf("Part %d has ETag %s, the last upload returned %s", part.PartNumber, part.ETag, f.etags[part.PartNumber])
+		}
+		data = append(data, f.parts[part.PartNumber]...)
+	}
+	f.completed = data
+	return "etag", nil
+}
+
+func (f *fakeCore) AbortMultipartUpload(ctx context.Context, bucket, object, uploadID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.aborted = true
+	return nil
+}
+
+func newTestSeekWriter(client MultipartClient, uploadBufferSize int64) *SeekWriter {
+	return NewSeekWriter(client, SeekWriterConfig{
+		Bucket:            "bucket",
+		Key:               "key",
+		PartSize:          4,
+		UploadConcurrency: 4,
+		UploadBufferSize:  uploadBufferSize,
+	})
+}
+
+// waitStarted waits for the uploads of the given number of parts to start.
+func waitStarted(t *testing.T, f *fakeCore, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %d uploads to start", n)
+		}
+	}
+}
+
+func TestSeekWriterSeekIntoInflightPart(t *testing.T) {
+	f := newFakeCore()
+	f.release = make(chan struct{})
+	w := newTestSeekWriter(f, 64)
+
+	if _, err := w.Write([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	// The second and third parts are uploaded, the first and the one before the current are kept
+	waitStarted(t, f, 2)
+
+	if _, err := w.Seek(5, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("XY")); err != nil {
+		t.Fatal(err)
+	}
+	close(f.release)
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "01234XY789abcdef"; string(f.completed) != expected {
+		t.Errorf("Expected object %q, got %q", expected, f.completed)
+	}
+}
+
+func TestSeekWriterCountsUploadedBytes(t *testing.T) {
+	f := newFakeCore()
+	var uploaded uint64
+	w := NewSeekWriter(f, SeekWriterConfig{
+		Bucket:            "bucket",
+		Key:               "key",
+		PartSize:          4,
+		UploadConcurrency: 4,
+		UploadBufferSize:  64,
+		Uploaded:          &uploaded,
+	})
+
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if count := atomic.LoadUint64(&uploaded); count != 10 {
+		t.Errorf("Expected 10 bytes uploaded, got %d", count)
+	}
+}
+
+func TestSeekWriterUploadBufferBlocksWrites(t *testing.T) {
+	f := newFakeCore()
+	f.release = make(chan struct{})
+	// Room for a single part in flight
+	w := newTestSeekWriter(f, 4)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("0123456789abcdef"))
+		done <- err
+	}()
+	waitStarted(t, f, 1)
+
+	select {
+	case err := <-done:
+		t.Fatalf("Write returned with the upload buffer full: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(f.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write did not return after the upload buffer drained")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0123456789abcdef"; string(f.completed) != expected {
+		t.Errorf("Expected object %q, got %q", expected, f.completed)
+	}
+}
+
+func TestSeekWriterUploadErrorReachesWrite(t *testing.T) {
+	f := newFakeCore()
+	f.failPart = 2
+	w := newTestSeekWriter(f, 64)
+
+	if _, err := w.Write([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	deadline := time.Now().Add(5 * time.Second)
+	for err == nil && time.Now().Before(deadline) {
+		_, err = w.Write([]byte("0"))
+		time.Sleep(time.Millisecond)
+	}
+	if err != errUpload {
+		t.Fatalf("Expected the upload error from Write, got %v", err)
+	}
+
+	if err := w.Close(); err != errUpload {
+		t.Errorf("Expected the upload error from Close, got %v", err)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if !f.aborted {
+		t.Error("Expected the multipart upload to be aborted")
+	}
+}
+
+func TestSeekWriterEmptyObject(t *testing.T) {
+	f := newFakeCore()
+	w := newTestSeekWriter(f, 64)
+	w.SetDefaultContentType("video/mp4")
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f.putOpts == nil {
+		t.Fatal("Expected an empty object to be stored")
+	}
+	if len(f.put) != 0 {
+		t.Errorf("Expected an empty object, got %q", f.put)
+	}
+	if f.putOpts.ContentType != "video/mp4" {
+		t.Errorf("Expected the default content type, got %q", f.putOpts.ContentType)
+	}
+	if f.uploads != 0 || f.completed != nil {
+		t.Error("Expected no multipart upload for an empty object")
+	}
+}
@@ -22,9 +22,13 @@ import (
 	"errors"
 	"io/ioutil"
 	"path"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
 	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -71,18 +75,23 @@ type PipelineManager struct {
 	stopChan   chan struct{}
 	running    bool
 	mux        sync.Mutex
+	// the time of the last scheduled run and the time the schedule was first set up, only accessed
+	// from the watch goroutine
+	lastRun, scheduledSince time.Time
 }
 
 var marker = ".gst-watch"
 
-// Start starts the pipeline manager.
-func (p *PipelineManager) Start() error {
+// Start starts the pipeline manager with the given pipeline configuration. A manager that was
+// stopped may have been holding an older configuration, so it is always replaced.
+func (p *PipelineManager) Start(cfg pipelinetypes.Pipeline) error {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
 	if p.running {
 		return errors.New("pipeline manager is already running")
 	}
+	p.pipeline = cfg
 
 	srcConfigFull := p.pipeline.GetSrcConfig()
 	if srcConfigFull.MinIO == nil {
@@ -95,11 +104,18 @@ func (p *PipelineManager) Start() error {
 		return err
 	}
 
+	sse, err := srcConfig.GetServerSideEncryption(p.client, p.pipeline.GetNamespace())
+	if err != nil {
+		return err
+	}
+
 	markerName := path.Join(srcConfig.GetPrefix(), marker)
 
 	// Check for a marker in the prefix we are watching. This checks for the existence of the
 	// bucket as well as ensure the subsequent watch works correctly.
-	obj, err := client.GetObject(context.TODO(), srcConfig.GetBucket(), markerName, minio.GetObjectOptions{})
+	obj, err := client.GetObject(context.TODO(), srcConfig.GetBucket(), markerName, minio.GetObjectOptions{
+		ServerSideEncryption: util.ReadEncryption(sse),
+	})
 	if err != nil {
 		return err
 	}
@@ -110,7 +126,9 @@ func (p *PipelineManager) Start() error {
 			switch resErr.Code {
 			case "NoSuchKey":
 				log.Info("Laying watch marker in bucket prefix", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix())
-				if _, err := client.PutObject(context.TODO(), srcConfig.GetBucket(), markerName, bytes.NewReader([]byte{}), 0, minio.PutObjectOptions{}); err != nil {
+				if _, err := client.PutObject(context.TODO(), srcConfig.GetBucket(), markerName, bytes.NewReader([]byte{}), 0, minio.PutObjectOptions{
+					ServerSideEncryption: sse,
+				}); err != nil {
 					return err
 				}
 			default:
@@ -148,40 +166,193 @@ func (p *PipelineManager) Stop() {
 }
 
 func (p *PipelineManager) watchSrcBucket(srcConfig *pipelinesmeta.MinIOConfig, client *minio.Client) {
-	log.Info("Watching for object created events", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix())
-	eventChan := client.ListenBucketNotification(context.Background(), srcConfig.GetBucket(), srcConfig.GetPrefix(), "", []string{"s3:ObjectCreated:*"})
-	excludeRegex := srcConfig.GetExcludeRegex()
+	ctx, cancel := context.WithCancel(context.Background())
+	eventChan, tickChan := p.subscribe(ctx, srcConfig, client)
 	for {
 		select {
 		case event := <-eventChan:
+			excludeRegex := srcConfig.GetExcludeRegex()
 			for _, record := range event.Records {
 				log.Info("Processing record from MinIO event", "Record", record)
-				if excludeRegex != nil && excludeRegex.MatchString(record.S3.Object.Key) {
-					log.Info("Skipping processing for item matching exclude regex", "Object", record.S3.Object.Key)
+				if !p.shouldProcess(record.S3.Object.Key, excludeRegex, nil) {
 					continue
 				}
-				p.createJob(srcConfig, record.S3.Object.Key)
+				p.createJob(srcConfig, client, record.S3.Object.Key, record.S3.Object.VersionID, record.S3.Object.ETag)
 			}
+		case <-tickChan:
+			p.runSchedule(ctx, srcConfig, client)
+			eventChan, tickChan = p.subscribe(ctx, srcConfig, client)
 		case <-p.reloadChan:
+			cancel()
+			ctx, cancel = context.WithCancel(context.Background())
 			srcConfig = p.pipeline.GetSrcConfig().MinIO // TODO
-			excludeRegex = srcConfig.GetExcludeRegex()
 			log.Info("Reloading event channel", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix())
-			eventChan = client.ListenBucketNotification(context.Background(), srcConfig.GetBucket(), srcConfig.GetPrefix(), "", []string{"s3:ObjectCreated:*"})
+			eventChan, tickChan = p.subscribe(ctx, srcConfig, client)
 		case <-p.stopChan:
+			cancel()
 			return
 		}
 	}
 }
 
-func (p *PipelineManager) createJob(srcConfig *pipelinesmeta.MinIOConfig, object string) {
-	log.Info("Creating pipeline job", "Bucket", srcConfig.GetBucket(), "Key", object)
-	job := p.newJobForObject(object)
+// subscribe returns the channels to receive work from for the current configuration. Pipelines
+// with a schedule receive a tick at the next scheduled time instead of bucket notifications.
+func (p *PipelineManager) subscribe(ctx context.Context, srcConfig *pipelinesmeta.MinIOConfig, client *minio.Client) (<-chan notification.Info, <-chan time.Time) {
+	if sp, ok := p.pipeline.(pipelinetypes.ScheduledPipeline); ok && sp.GetSchedule() != nil {
+		schedule, err := sp.GetSchedule().Parse()
+		if err != nil {
+			log.Error(err, "Not scheduling processing for bucket prefix", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix())
+			return nil, nil
+		}
+		now := time.Now()
+		if p.scheduledSince.IsZero() {
+			p.scheduledSince = now
+		}
+		next := schedule.Next(now)
+		log.Info("Scheduling processing of bucket prefix", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix(), "Next", next)
+		return nil, time.After(time.Until(next))
+	}
+	log.Info("Watching for object created events", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix())
+	return client.ListenBucketNotification(ctx, srcConfig.GetBucket(), srcConfig.GetPrefix(), "", []string{"s3:ObjectCreated:*"}), nil
+}
+
+// shouldProcess returns true if a job should be created for the given key.
+func (p *PipelineManager) shouldProcess(key string, excludeRegex, includeRegex *regexp.Regexp) bool {
+	if path.Base(key) == marker {
+		return false
+	}
+	if pipelinesmeta.IsSidecarObject(key) {
+		log.Info("Skipping processing for job result or metadata object", "Object", key)
+		return false
+	}
+	if excludeRegex != nil && excludeRegex.MatchString(key) {
+		log.Info("Skipping processing for item matching exclude regex", "Object", key)
+		return false
+	}
+	if includeRegex != nil && !includeRegex.MatchString(key) {
+		log.Info("Skipping processing for item not matching include regex", "Object", key)
+		return false
+	}
+	if mp, ok := p.pipeline.(pipelinetypes.ManifestPipeline); ok && !mp.IsManifest(key) {
+		log.Info("Skipping processing for item not matching manifest regex", "Object", key)
+		return false
+	}
+	return true
+}
+
+// runSchedule lists the objects in the source prefix and creates jobs for the ones matching
+// the filters and modified-since window of the schedule. The results are recorded in the status
+// of the pipeline.
+func (p *PipelineManager) runSchedule(ctx context.Context, srcConfig *pipelinesmeta.MinIOConfig, client *minio.Client) {
+	sp, ok := p.pipeline.(pipelinetypes.ScheduledPipeline)
+	if !ok || sp.GetSchedule() == nil {
+		return
+	}
+	sched := sp.GetSchedule()
+	now := time.Now()
+
+	since := modifiedSince(sched, sp.GetScheduleStatus(), now, p.lastRun, p.scheduledSince)
+	p.lastRun = now
+
+	log.Info("Running scheduled processing of bucket prefix", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix(), "ModifiedSince", since)
+	status := &pipelinesmeta.ScheduleStatus{LastRunTime: &metav1.Time{Time: now}}
+	excludeRegex := srcConfig.GetExcludeRegex()
+	includeRegex := sched.GetIncludeRegex()
+	for obj := range client.ListObjects(ctx, srcConfig.GetBucket(), minio.ListObjectsOptions{Prefix: srcConfig.GetPrefix(), Recursive: true}) {
+		if obj.Err != nil {
+			log.Error(obj.Err, "Failed to list objects in bucket prefix", "Bucket", srcConfig.GetBucket(), "Prefix", srcConfig.GetPrefix())
+			status.LastRunErrors++
+			break
+		}
+		if strings.HasSuffix(obj.Key, "/") || !obj.LastModified.After(since) {
+			continue
+		}
+		if !p.shouldProcess(obj.Key, excludeRegex, includeRegex) {
+			continue
+		}
+		status.LastRunObjects++
+		if err := p.createJob(srcConfig, client, obj.Key, obj.VersionID, obj.ETag); err != nil {
+			status.LastRunErrors++
+			continue
+		}
+		status.LastRunJobs++
+	}
+
+	if schedule, err := sched.Parse(); err == nil {
+		status.NextRunTime = &metav1.Time{Time: schedule.Next(now)}
+	}
+	log.Info("Scheduled processing finished", "Objects", status.LastRunObjects, "Jobs", status.LastRunJobs, "Errors", status.LastRunErrors)
+
+	p.setScheduleStatus(ctx, sp, status)
+}
+
+// modifiedSince returns the time objects must have been modified after to be processed by a scheduled
+// run at now. Without a modifiedWithin window, this is the time of the previous run, recorded either by
+// this manager or in the status. When the source has never been processed, it is the time the schedule
+// was set up, so the first run does not process every object that ever existed in the source.
+func modifiedSince(sched *pipelinesmeta.ScheduleConfig, status *pipelinesmeta.ScheduleStatus, now, lastRun, scheduledSince time.Time) time.Time {
+	if sched.ModifiedWithin != nil {
+		return now.Add(-sched.ModifiedWithin.Duration)
+	}
+	since := lastRun
+	if status != nil && status.LastRunTime != nil && status.LastRunTime.After(since) {
+		since = status.LastRunTime.Time
+	}
+	if since.IsZero() {
+		since = scheduledSince
+	}
+	return since
+}
+
+func (p *PipelineManager) setScheduleStatus(ctx context.Context, sp pipelinetypes.ScheduledPipeline, status *pipelinesmeta.ScheduleStatus) {
+	updated := sp.DeepCopyObject().(pipelinetypes.ScheduledPipeline)
+	updated.SetScheduleStatus(status)
+	if err := p.client.Status().Patch(ctx, updated, client.MergeFrom(sp)); err != nil {
+		log.Error(err, "Failed to record the results of scheduled processing")
+	}
+}
+
+// createJob creates a job for the given object. The version ID and ETag, when known, pin the job to
+// the contents of the object at the time it was seen.
+func (p *PipelineManager) createJob(srcConfig *pipelinesmeta.MinIOConfig, client *minio.Client, object, versionID, etag string) error {
+	log.Info("Creating pipeline job", "Bucket", srcConfig.GetBucket(), "Key", object, "VersionID", versionID)
+	job := p.newJobForObject(object, versionID, etag)
+	if mp, ok := p.pipeline.(pipelinetypes.ManifestPipeline); ok {
+		inputs, err := p.getManifestInputs(mp, srcConfig, client, object, versionID)
+		if err != nil {
+			log.Error(err, "Failed to resolve the objects referenced by manifest", "Key", object)
+			return err
+		}
+		job.Spec.Inputs = inputs
+	}
 	if err := p.client.Create(context.TODO(), job); err != nil {
 		log.Error(err, "Failed to create processing job for object")
+		return err
+	}
+	return nil
+}
+
+func (p *PipelineManager) getManifestInputs(mp pipelinetypes.ManifestPipeline, srcConfig *pipelinesmeta.MinIOConfig, client *minio.Client, key, versionID string) ([]*pipelinesmeta.Object, error) {
+	sse, err := srcConfig.GetServerSideEncryption(p.client, p.pipeline.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(context.TODO(), srcConfig.GetBucket(), key, minio.GetObjectOptions{
+		ServerSideEncryption: util.ReadEncryption(sse),
+		VersionID:            versionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	body, err := ioutil.ReadAll(obj)
+	if err != nil {
+		return nil, err
 	}
+	return mp.GetInputObjects(key, body)
 }
 
-func (p *PipelineManager) newJobForObject(key string) *pipelinesv1.Job {
+func (p *PipelineManager) newJobForObject(key, versionID, etag string) *pipelinesv1.Job {
 	job := &pipelinesv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName:    p.pipeline.GetName(),
@@ -195,8 +366,10 @@ func (p *PipelineManager) newJobForObject(key string) *pipelinesv1.Job {
 				Kind: p.pipeline.GetPipelineKind(),
 			},
 			Source: &pipelinesmeta.Object{
-				Name:   key,
-				Config: p.pipeline.GetSrcConfig(),
+				Name:      key,
+				VersionID: versionID,
+				ETag:      etag,
+				Config:    p.pipeline.GetSrcConfig(),
 			},
 			Sinks: p.pipeline.GetSinkObjects(key),
 		},
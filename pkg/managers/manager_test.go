@@ -0,0 +1,125 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
+)
+
+func TestModifiedSince(t *testing.T) {
+	now := time.Date(2021, 1, 2, 2, 0, 0, 0, time.UTC)
+	scheduledSince := now.Add(-6 * time.Hour)
+	lastRun := now.Add(-24 * time.Hour)
+	statusRun := now.Add(-12 * time.Hour)
+
+	withStatus := func(t time.Time) *pipelinesmeta.ScheduleStatus {
+		return &pipelinesmeta.ScheduleStatus{LastRunTime: &metav1.Time{Time: t}}
+	}
+
+	tests := []struct {
+		name     string
+		sched    *pipelinesmeta.ScheduleConfig
+		status   *pipelinesmeta.ScheduleStatus
+		lastRun  time.Time
+		expected time.Time
+	}{
+		{
+			name:     "first run starts at the time the schedule was set up",
+			sched:    &pipelinesmeta.ScheduleConfig{Cron: "@daily"},
+			expected: scheduledSince,
+		},
+		{
+			name:     "first run with an empty status",
+			sched:    &pipelinesmeta.ScheduleConfig{Cron: "@daily"},
+			status:   &pipelinesmeta.ScheduleStatus{},
+			expected: scheduledSince,
+		},
+		{
+			name:     "previous run of this manager",
+			sched:    &pipelinesmeta.ScheduleConfig{Cron: "@daily"},
+			lastRun:  lastRun,
+			expected: lastRun,
+		},
+		{
+			name:     "previous run recorded in the status",
+			sched:    &pipelinesmeta.ScheduleConfig{Cron: "@daily"},
+			status:   withStatus(statusRun),
+			expected: statusRun,
+		},
+		{
+			name:     "latest of the previous runs",
+			sched:    &pipelinesmeta.ScheduleConfig{Cron: "@daily"},
+			status:   withStatus(statusRun),
+			lastRun:  lastRun,
+			expected: statusRun,
+		},
+		{
+			name:     "modified within window",
+			sched:    &pipelinesmeta.ScheduleConfig{Cron: "@daily", ModifiedWithin: &metav1.Duration{Duration: time.Hour}},
+			status:   withStatus(statusRun),
+			lastRun:  lastRun,
+			expected: now.Add(-time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if since := modifiedSince(tt.sched, tt.status, now, tt.lastRun, scheduledSince); !since.Equal(tt.expected) {
+				t.Errorf("Expected objects modified since %s, got %s", tt.expected, since)
+			}
+		})
+	}
+}
+
+func TestScheduleParse(t *testing.T) {
+	from := time.Date(2021, 1, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		cron      string
+		expected  time.Time
+		expectErr bool
+	}{
+		{cron: "0 2 * * *", expected: time.Date(2021, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{cron: "@every 6h", expected: from.Add(6 * time.Hour)},
+		{cron: "@daily", expected: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{cron: "CRON_TZ=Europe/Berlin 0 14 * * *", expected: time.Date(2021, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{cron: "0 2 * *", expectErr: true},
+		{cron: "every night", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cron, func(t *testing.T) {
+			schedule, err := (&pipelinesmeta.ScheduleConfig{Cron: tt.cron}).Parse()
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("Expected an error parsing the schedule")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next := schedule.Next(from); !next.Equal(tt.expected) {
+				t.Errorf("Expected the next run at %s, got %s", tt.expected, next)
+			}
+		})
+	}
+}
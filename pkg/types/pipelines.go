@@ -19,6 +19,7 @@ package types
 import (
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // Pipeline is a generic interface implemented by the different Pipeline types.
@@ -43,3 +44,31 @@ type Pipeline interface {
 	// source key.
 	GetSinkObjects(srcKey string) []*pipelinesmeta.Object
 }
+
+// ManifestPipeline is implemented by pipelines that are triggered by manifest objects
+// listing the actual inputs to the pipeline.
+type ManifestPipeline interface {
+	Pipeline
+
+	// IsManifest should return true if the given key should be treated as a manifest.
+	// Other objects created in the source prefix are ignored.
+	IsManifest(key string) bool
+	// GetInputObjects should parse the given manifest and return the objects that it
+	// references.
+	GetInputObjects(manifestKey string, manifest []byte) ([]*pipelinesmeta.Object, error)
+}
+
+// ScheduledPipeline is implemented by pipelines that can process their source on a schedule
+// instead of as objects are created.
+type ScheduledPipeline interface {
+	Pipeline
+	// Extends the runtime.Object interface so the status can be updated
+	runtime.Object
+
+	// GetSchedule should return the schedule for the pipeline, or nil if it is not scheduled.
+	GetSchedule() *pipelinesmeta.ScheduleConfig
+	// GetScheduleStatus should return the results of the last scheduled run.
+	GetScheduleStatus() *pipelinesmeta.ScheduleStatus
+	// SetScheduleStatus should set the results of the last scheduled run on the status.
+	SetScheduleStatus(*pipelinesmeta.ScheduleStatus)
+}
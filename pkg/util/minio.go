@@ -24,6 +24,7 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	pipelinesmeta "github.com/tinyzimmer/gst-pipeline-operator/apis/meta/v1"
@@ -56,6 +57,16 @@ func (s *srcCredentialsFromEnv) GetCredentials() (*credentials.Credentials, erro
 	return credentials.NewStaticV4(os.Getenv(pipelinesmeta.MinIOSrcAccessKeyIDEnvVar), os.Getenv(pipelinesmeta.MinIOSrcSecretAccessKeyEnvVar), ""), nil
 }
 
+// MinIODebugCredentialsFromEnv returns a credentials getter that retrieves the credentials
+// from the environment variables configured by the controller for the debug destination.
+func MinIODebugCredentialsFromEnv() MinIOCredentialsGetter { return &debugCredentialsFromEnv{} }
+
+type debugCredentialsFromEnv struct{}
+
+func (s *debugCredentialsFromEnv) GetCredentials() (*credentials.Credentials, error) {
+	return credentials.NewStaticV4(os.Getenv(pipelinesmeta.MinIODebugAccessKeyIDEnvVar), os.Getenv(pipelinesmeta.MinIODebugSecretAccessKeyEnvVar), ""), nil
+}
+
 // MinIOWatchCredentialsFromCR returns a credentials getter that uses the given client
 // and CR to produce credentials to the bucket being watched for transformations.
 func MinIOWatchCredentialsFromCR(client client.Client, cr types.Pipeline) MinIOCredentialsGetter {
@@ -107,3 +118,19 @@ func GetMinIOClient(cfg *pipelinesmeta.MinIOConfig, credsGetter MinIOCredentials
 		Transport: transport,
 	})
 }
+
+// GetServerSideEncryption returns the encryption for objects in the given configuration, reading
+// the SSE-C customer key, if one is used, from the given environment variable.
+func GetServerSideEncryption(cfg *pipelinesmeta.MinIOConfig, customerKeyEnvVar string) (encrypt.ServerSide, error) {
+	return cfg.Encryption.GetServerSide(os.Getenv(customerKeyEnvVar))
+}
+
+// ReadEncryption returns the encryption to send when reading objects stored with the given encryption.
+// Only SSE-C keys are sent on reads, the server decrypts SSE-S3 and SSE-KMS objects on its own and
+// rejects reads carrying their headers.
+func ReadEncryption(sse encrypt.ServerSide) encrypt.ServerSide {
+	if sse != nil && sse.Type() == encrypt.SSEC {
+		return sse
+	}
+	return nil
+}